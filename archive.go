@@ -0,0 +1,130 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"zee/archive"
+	"zee/config"
+	"zee/log"
+	"zee/transcriber"
+)
+
+// With config.json's "archive" on, every finished dictation — failures too,
+// cancellations not — is kept in the archive (package archive): the audio as
+// sent and an info.json with the transcript, the session's full metrics, the
+// hints and the language. samples/ holds what was saved by hand or failed;
+// the archive is the whole corpus. It is written off the paste path.
+
+// archiveInfo is an archived recording's info.json. The keys samples/ uses
+// (provider, model, format, text, error, timestamp) mean the same here.
+type archiveInfo struct {
+	Timestamp string                    `json:"timestamp"`
+	Provider  string                    `json:"provider"`
+	Model     string                    `json:"model"`
+	Format    string                    `json:"format"`
+	Language  string                    `json:"language"`
+	Hints     string                    `json:"hints,omitempty"`
+	Stream    bool                      `json:"stream"`
+	Text      string                    `json:"text"`                // the raw transcript
+	Delivered string                    `json:"delivered,omitempty"` // after post-processing, when it differs
+	Error     string                    `json:"error,omitempty"`
+	DurationS float64                   `json:"duration_s"`
+	FeltMs    float64                   `json:"felt_ms,omitempty"` // release → text
+	Latency   log.LatencyBreakdown      `json:"latency"`
+	HistoryID int                       `json:"history_id,omitempty"`
+	Result    transcriber.SessionResult `json:"result"`
+}
+
+var (
+	archiveMu   sync.Mutex // one save-and-prune at a time
+	archiveOpen *archive.Archive
+	archiveKey  string // the settings archiveOpen was opened with
+)
+
+// archivePassphraseEnv holds the archive passphrase outside the config dir.
+const archivePassphraseEnv = "ZEE_ARCHIVE_PASSPHRASE"
+
+// openArchive opens the configured archive, reusing the open one while the
+// settings and passphrase stay the same: the key derivation is slow on
+// purpose. Called with archiveMu held.
+//
+// The passphrase comes from $ZEE_ARCHIVE_PASSPHRASE, else credentials.json.
+// The latter sits in the config dir beside the default archive, so it only
+// protects copies of the archive (a backup, a synced or stolen folder), not
+// the archive from whoever can read the config dir — the same user or the
+// disk. The variable, set from a keychain at login, keeps the two apart.
+func openArchive() (*archive.Archive, error) {
+	c := config.Get().Archive
+	dir := cmp.Or(c.Dir, filepath.Join(config.Dir(), "archive"))
+	var pass string
+	if c.Encrypt {
+		// Never fall back to writing voice in the clear.
+		if pass = cmp.Or(os.Getenv(archivePassphraseEnv), config.APIKey("archive")); pass == "" {
+			return nil, fmt.Errorf(`encrypt is on but neither $%s nor credentials.json's "archive" holds a passphrase`,
+				archivePassphraseEnv)
+		}
+	}
+	key := fmt.Sprintf("%s\x00%s\x00%d\x00%g", dir, pass, c.MaxDays, c.MaxGB)
+	if archiveOpen != nil && key == archiveKey {
+		return archiveOpen, nil
+	}
+	a, err := archive.Open(dir, pass, archive.Retention{
+		MaxAge:   time.Duration(c.MaxDays) * 24 * time.Hour,
+		MaxBytes: int64(c.MaxGB * 1e9),
+	})
+	if err != nil {
+		return nil, err
+	}
+	archiveOpen, archiveKey = a, key
+	return a, nil
+}
+
+// archiveRecording stores result's audio with info — the caller fills in how
+// the cycle ended; the rest comes from result and cfg — and links the
+// history entry to it.
+func archiveRecording(result transcriber.SessionResult, cfg recordingConfig, info archiveInfo) {
+	if !config.Get().Archive.Enabled || len(result.AudioData) == 0 {
+		return
+	}
+	now := time.Now()
+	info.Timestamp = now.Format(time.RFC3339)
+	info.Provider, info.Model = cfg.tr.Name(), cfg.tr.GetModel()
+	info.Format, info.Language, info.Hints = result.AudioFormat, cfg.lang, cfg.hints
+	info.Stream = cfg.stream
+	info.Text = result.Text
+	if info.Delivered == info.Text {
+		info.Delivered = ""
+	}
+	info.HistoryID = cfg.historyID
+	info.Result = result
+	go func() {
+		archiveMu.Lock()
+		defer archiveMu.Unlock()
+		a, err := openArchive()
+		if err != nil {
+			log.Warnf("archive: %v", err)
+			return
+		}
+		meta, _ := json.MarshalIndent(info, "", "  ")
+		dir, err := a.Save(now, map[string][]byte{
+			"audio." + result.AudioFormat: result.AudioData,
+			archive.InfoName:              meta,
+		})
+		if err != nil {
+			log.Warnf("archive: %v", err)
+			return
+		}
+		linkHistoryAudio(info.HistoryID, dir)
+		if n, err := a.Prune(now); err != nil {
+			log.Warnf("archive: prune: %v", err)
+		} else if n > 0 {
+			log.Info(fmt.Sprintf("archive: pruned %d recordings", n))
+		}
+	}()
+}
@@ -0,0 +1,300 @@
+// Package archive keeps recordings — audio plus info.json — in one folder per
+// dictation, optionally sealed with a key derived from a passphrase, and
+// prunes them by age and total size. Folder names are sortable timestamps, so
+// oldest is lexical order, as in samples/.
+package archive
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncExt marks a sealed file: info.json is stored as info.json.enc.
+const EncExt = ".enc"
+
+// InfoName is the file every recording folder holds, sealed or not. Folders
+// and Prune only take a timestamp-named folder with one for a recording, so
+// an archive dir shared with anything else — ~/Documents — keeps the rest.
+const InfoName = "info.json"
+
+// folderLayout names a recording's folder by its start.
+const folderLayout = "2006-01-02T15-04-05"
+
+// keyFile holds the key derivation parameters, never the key.
+const keyFile = "key.json"
+
+// magic leads every sealed file, ahead of the GCM nonce.
+const magic = "ZEEARC1\n"
+
+// kdfIterations is PBKDF2-SHA256's work factor for a new archive (OWASP's
+// 2023 figure), kept in key.json; paid once per Open, not per file. A var so
+// tests can lower it.
+var kdfIterations = 600_000
+
+var (
+	ErrPassphrase = errors.New("wrong archive passphrase")
+	ErrSealed     = errors.New("archive file is encrypted; a passphrase is needed")
+)
+
+// Retention bounds the archive. Zero fields don't limit.
+type Retention struct {
+	MaxAge   time.Duration
+	MaxBytes int64
+}
+
+// Archive is a directory of recording folders.
+type Archive struct {
+	dir string
+	key []byte // nil: files are written in the clear
+	ret Retention
+}
+
+type keyParams struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Check      []byte `json:"check"` // a known plaintext sealed with the key
+}
+
+const checkText = "zee archive key"
+
+// Open opens (creating) the archive at dir. With a passphrase, new files are
+// sealed and sealed files can be read; the first Open with one fixes the
+// archive's salt, and every later one must use the same passphrase.
+func Open(dir, passphrase string, ret Retention) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	a := &Archive{dir: dir, ret: ret}
+	if passphrase == "" {
+		return a, nil
+	}
+	path := filepath.Join(dir, keyFile)
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		p := keyParams{KDF: "pbkdf2-sha256", Iterations: kdfIterations, Salt: make([]byte, 16)}
+		rand.Read(p.Salt)
+		if a.key, err = deriveKey(passphrase, p); err != nil {
+			return nil, err
+		}
+		if p.Check, err = seal(a.key, []byte(checkText)); err != nil {
+			return nil, err
+		}
+		data, _ := json.MarshalIndent(p, "", "  ")
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return nil, err
+		}
+		return a, nil
+	case err != nil:
+		return nil, err
+	}
+	var p keyParams
+	if err := json.Unmarshal(data, &p); err != nil || p.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("%s: unreadable key parameters", path)
+	}
+	if a.key, err = deriveKey(passphrase, p); err != nil {
+		return nil, err
+	}
+	if check, err := open(a.key, p.Check); err != nil || string(check) != checkText {
+		return nil, ErrPassphrase
+	}
+	return a, nil
+}
+
+func deriveKey(passphrase string, p keyParams) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, p.Salt, p.Iterations, 32)
+}
+
+func (a *Archive) Dir() string { return a.dir }
+
+// Encrypted reports whether Save seals what it writes.
+func (a *Archive) Encrypted() bool { return a.key != nil }
+
+// Save writes files into a new folder named for at and returns the folder.
+// Sealed files get EncExt appended to their names.
+func (a *Archive) Save(at time.Time, files map[string][]byte) (string, error) {
+	name := at.Format(folderLayout)
+	folder := filepath.Join(a.dir, name)
+	for i := 2; ; i++ {
+		err := os.Mkdir(folder, 0700)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		folder = filepath.Join(a.dir, fmt.Sprintf("%s-%d", name, i))
+	}
+	for fname, data := range files {
+		if a.key != nil {
+			sealed, err := seal(a.key, data)
+			if err != nil {
+				return folder, err
+			}
+			data, fname = sealed, fname+EncExt
+		}
+		if err := os.WriteFile(filepath.Join(folder, fname), data, 0600); err != nil {
+			return folder, err
+		}
+	}
+	return folder, nil
+}
+
+// ReadFile reads name from folder, unsealing name+EncExt when that is what
+// was stored.
+func (a *Archive) ReadFile(folder, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(folder, name))
+	if !errors.Is(err, fs.ErrNotExist) {
+		return data, err
+	}
+	sealed, serr := os.ReadFile(filepath.Join(folder, name+EncExt))
+	if serr != nil {
+		return nil, err // the plain name's not-exist is the clearer error
+	}
+	if a.key == nil {
+		return nil, ErrSealed
+	}
+	return open(a.key, sealed)
+}
+
+// Folders lists the recording folders, oldest first.
+func (a *Archive) Folders() ([]string, error) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		return nil, err
+	}
+	var folders []string
+	for _, e := range entries {
+		folder := filepath.Join(a.dir, e.Name())
+		if e.IsDir() && isRecording(folder) {
+			folders = append(folders, folder)
+		}
+	}
+	return folders, nil
+}
+
+// isRecording reports whether folder is one Save made: named for a start
+// time (with Save's "-2" suffix at most) and holding InfoName.
+func isRecording(folder string) bool {
+	if _, ok := folderTime(folder); !ok {
+		return false
+	}
+	if suffix := filepath.Base(folder)[len(folderLayout):]; suffix != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(suffix, "-"))
+		if suffix[0] != '-' || err != nil || n < 2 {
+			return false
+		}
+	}
+	for _, name := range []string{InfoName, InfoName + EncExt} {
+		if info, err := os.Stat(filepath.Join(folder, name)); err == nil && info.Mode().IsRegular() {
+			return true
+		}
+	}
+	return false
+}
+
+// Prune deletes the oldest folders until retention holds: none older than
+// MaxAge (by folder name), and all together within MaxBytes. It returns how
+// many it deleted.
+func (a *Archive) Prune(now time.Time) (int, error) {
+	folders, err := a.Folders()
+	if err != nil {
+		return 0, err
+	}
+	sizes := make([]int64, len(folders))
+	var total int64
+	for i, f := range folders {
+		sizes[i] = folderSize(f)
+		total += sizes[i]
+	}
+	removed := 0
+	for i, f := range folders {
+		old := false
+		if t, _ := folderTime(f); a.ret.MaxAge > 0 {
+			old = now.Sub(t) > a.ret.MaxAge
+		}
+		big := a.ret.MaxBytes > 0 && total > a.ret.MaxBytes
+		if !old && !big {
+			break
+		}
+		if err := os.RemoveAll(f); err != nil {
+			return removed, err
+		}
+		total -= sizes[i]
+		removed++
+	}
+	return removed, nil
+}
+
+// folderTime is when the recording in folder started, from its name (which
+// may carry a "-2" suffix).
+func folderTime(folder string) (time.Time, bool) {
+	name := filepath.Base(folder)
+	if len(name) < len(folderLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(folderLayout, name[:len(folderLayout)], time.Local)
+	return t, err == nil
+}
+
+func folderSize(dir string) int64 {
+	var n int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				n += info.Size()
+			}
+		}
+		return nil
+	})
+	return n
+}
+
+func seal(key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+	out := append([]byte(magic), nonce...)
+	return gcm.Seal(out, nonce, plain, []byte(magic)), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	rest, ok := strings.CutPrefix(string(sealed), magic)
+	if !ok || len(rest) < gcm.NonceSize() {
+		return nil, errors.New("not an archive file")
+	}
+	nonce, body := []byte(rest[:gcm.NonceSize()]), []byte(rest[gcm.NonceSize():])
+	plain, err := gcm.Open(nil, nonce, body, []byte(magic))
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
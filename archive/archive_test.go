@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func init() { kdfIterations = 1000 }
+
+func TestSealedRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	a, err := Open(dir, "correct horse", Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.Local)
+	audio := []byte("fLaC not really")
+	folder, err := a.Save(at, map[string][]byte{"audio.flac": audio, "info.json": []byte(`{"text":"hi"}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(folder) != "2026-03-01T09-30-00" {
+		t.Errorf("folder = %s", folder)
+	}
+	raw, err := os.ReadFile(filepath.Join(folder, "audio.flac"+EncExt))
+	if err != nil || bytes.Contains(raw, audio) {
+		t.Fatalf("stored audio readable or missing: %v", err)
+	}
+	if got, err := a.ReadFile(folder, "audio.flac"); err != nil || !bytes.Equal(got, audio) {
+		t.Fatalf("ReadFile = %q, %v", got, err)
+	}
+
+	// The same second gets its own folder.
+	if f2, _ := a.Save(at, map[string][]byte{"info.json": nil}); filepath.Base(f2) != "2026-03-01T09-30-00-2" {
+		t.Errorf("second folder = %s", f2)
+	}
+
+	if _, err := Open(dir, "wrong", Retention{}); !errors.Is(err, ErrPassphrase) {
+		t.Errorf("wrong passphrase: %v", err)
+	}
+	b, err := Open(dir, "correct horse", Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := b.ReadFile(folder, "info.json"); string(got) != `{"text":"hi"}` {
+		t.Errorf("reopened ReadFile = %q", got)
+	}
+	plain, _ := Open(dir, "", Retention{})
+	if _, err := plain.ReadFile(folder, "info.json"); !errors.Is(err, ErrSealed) {
+		t.Errorf("no passphrase: %v", err)
+	}
+}
+
+func TestPlain(t *testing.T) {
+	a, err := Open(t.TempDir(), "", Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	folder, _ := a.Save(time.Now(), map[string][]byte{"info.json": []byte("{}")})
+	if got, err := os.ReadFile(filepath.Join(folder, "info.json")); err != nil || string(got) != "{}" {
+		t.Fatalf("plain file = %q, %v", got, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	dir := t.TempDir()
+	a, _ := Open(dir, "", Retention{MaxAge: 7 * 24 * time.Hour, MaxBytes: 250})
+	for _, day := range []int{1, 5, 8, 9, 10} {
+		a.Save(time.Date(2026, 3, day, 8, 0, 0, 0, time.Local), map[string][]byte{
+			"audio.flac": make([]byte, 98),
+			InfoName:     []byte("{}"),
+		})
+	}
+	// An archive dir the user shares with other things: none of these are
+	// recordings, however old their names or big their contents.
+	for _, name := range []string{"Photos", "2026-02-01T08-00-00", "2026-02-01T08-00-00 copy", "2026-02-01T08-00-00-old"} {
+		os.MkdirAll(filepath.Join(dir, name), 0755)
+		os.WriteFile(filepath.Join(dir, name, "keep.txt"), make([]byte, 1000), 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "2026-02-01T08-00-00-old", InfoName), []byte("{}"), 0644)
+
+	// Day 1 is past a week; then 5 and 8 go to get under 250 bytes.
+	if n, err := a.Prune(now); err != nil || n != 3 {
+		t.Fatalf("Prune = %d, %v", n, err)
+	}
+	folders, _ := a.Folders()
+	if len(folders) != 2 || filepath.Base(folders[0]) != "2026-03-09T08-00-00" {
+		t.Errorf("left = %v", folders)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2+4 {
+		t.Errorf("%d entries left in the archive dir, want the 2 recordings and 4 others", len(entries))
+	}
+}
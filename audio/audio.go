@@ -11,25 +11,26 @@ import (
 
 const WAVHeaderSize = 44
 
-// WAVToPCM parses a RIFF/WAVE file and returns the raw PCM data chunk, after
-// validating it is 16 kHz mono signed-16-bit little-endian — the format the
-// local Parakeet engine expects. It walks the chunk list so padding chunks
-// (FLLR, LIST, fact, …) between the header and `data` are handled correctly.
+// WAVToPCM parses a RIFF/WAVE file and returns its audio as 16 kHz mono
+// signed-16-bit little-endian PCM — the format the local engines expect. It
+// walks the chunk list so padding chunks (FLLR, LIST, fact, …) between the
+// header and `data` are handled correctly. A file already in that format is
+// returned as is; any other PCM or float WAV (8/16/24/32-bit int, 32/64-bit
+// float, WAVE_FORMAT_EXTENSIBLE, any rate and channel count) is downmixed and
+// resampled (see convert.go).
 //
 // Use case: the `-transcribe <file.wav>` flow (main.go transcribeFile), which
-// feeds a WAV from disk to a local transcriber. Live recording captures raw PCM
-// and never hits this. In practice that flow is driven mostly by the integration
-// tests (test/integration_test.go transcribeFiles), so this is largely test-path
-// code, not the hot path.
+// feeds a WAV from disk to a local transcriber, and -benchmark. Live recording
+// captures raw PCM and never hits this. In practice that flow is driven mostly
+// by the integration tests (test/integration_test.go transcribeFiles), so this
+// is largely test-path code, not the hot path.
 func WAVToPCM(b []byte) ([]byte, error) {
 	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
 		return nil, fmt.Errorf("not a RIFF/WAVE file")
 	}
 	var (
-		gotFmt         bool
-		channels, bits uint16
-		sampleRate     uint32
-		data           []byte
+		fmtChunk []byte
+		data     []byte
 	)
 	for off := 12; off+8 <= len(b); {
 		id := string(b[off : off+4])
@@ -40,13 +41,7 @@ func WAVToPCM(b []byte) ([]byte, error) {
 		}
 		switch id {
 		case "fmt ":
-			if size < 16 {
-				return nil, fmt.Errorf("short fmt chunk")
-			}
-			channels = binary.LittleEndian.Uint16(b[body+2 : body+4])
-			sampleRate = binary.LittleEndian.Uint32(b[body+4 : body+8])
-			bits = binary.LittleEndian.Uint16(b[body+14 : body+16])
-			gotFmt = true
+			fmtChunk = b[body : body+size]
 		case "data":
 			data = b[body : body+size]
 		}
@@ -55,16 +50,20 @@ func WAVToPCM(b []byte) ([]byte, error) {
 			off++ // chunks are word-aligned
 		}
 	}
-	if !gotFmt {
+	if fmtChunk == nil {
 		return nil, fmt.Errorf("no fmt chunk")
 	}
 	if data == nil {
 		return nil, fmt.Errorf("no data chunk")
 	}
-	if channels != 1 || sampleRate != 16000 || bits != 16 {
-		return nil, fmt.Errorf("unsupported WAV format: %d-bit %d ch %d Hz (need 16-bit mono 16000 Hz)", bits, channels, sampleRate)
+	f, err := parseWAVFormat(fmtChunk)
+	if err != nil {
+		return nil, err
 	}
-	return data, nil
+	if f.isTarget() {
+		return data, nil
+	}
+	return toTargetPCM(data, f), nil
 }
 
 // PCMToF32 converts raw signed-16-bit little-endian PCM (the capture format,
@@ -0,0 +1,258 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// WAV fmt codes. WAVE_FORMAT_EXTENSIBLE carries the real one in the first two
+// bytes of its SubFormat GUID.
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// targetRate is what the local engines (and live capture) run at.
+const targetRate = 16000
+
+type wavFormat struct {
+	tag        uint16 // resolved: wavFormatPCM or wavFormatFloat
+	channels   int
+	sampleRate int
+	bits       int // container bits per sample
+}
+
+func parseWAVFormat(fmtChunk []byte) (wavFormat, error) {
+	if len(fmtChunk) < 16 {
+		return wavFormat{}, fmt.Errorf("short fmt chunk")
+	}
+	f := wavFormat{
+		tag:        binary.LittleEndian.Uint16(fmtChunk[0:2]),
+		channels:   int(binary.LittleEndian.Uint16(fmtChunk[2:4])),
+		sampleRate: int(binary.LittleEndian.Uint32(fmtChunk[4:8])),
+		bits:       int(binary.LittleEndian.Uint16(fmtChunk[14:16])),
+	}
+	if f.tag == wavFormatExtensible {
+		if len(fmtChunk) < 26 {
+			return wavFormat{}, fmt.Errorf("short WAVE_FORMAT_EXTENSIBLE fmt chunk")
+		}
+		f.tag = binary.LittleEndian.Uint16(fmtChunk[24:26])
+	}
+	if f.channels < 1 || f.sampleRate < 1 {
+		return wavFormat{}, fmt.Errorf("bad WAV format: %d ch %d Hz", f.channels, f.sampleRate)
+	}
+	switch {
+	case f.tag == wavFormatPCM && (f.bits == 8 || f.bits == 16 || f.bits == 24 || f.bits == 32):
+	case f.tag == wavFormatFloat && (f.bits == 32 || f.bits == 64):
+	default:
+		return wavFormat{}, fmt.Errorf("unsupported WAV encoding: format %#x, %d-bit", f.tag, f.bits)
+	}
+	return f, nil
+}
+
+func (f wavFormat) isTarget() bool {
+	return f.tag == wavFormatPCM && f.bits == 16 && f.channels == 1 && f.sampleRate == targetRate
+}
+
+// toTargetPCM decodes interleaved samples in format f, downmixes them to mono
+// and resamples to 16 kHz signed-16-bit PCM. A trailing partial frame is
+// dropped. The work goes a PCMStream block at a time, so only the output is
+// held whole — an hour of 48 kHz stereo never becomes an hour of float64s.
+func toTargetPCM(data []byte, f wavFormat) []byte {
+	frames := len(data) / (f.bits / 8 * f.channels)
+	out := make([]byte, 0, int(int64(frames)*targetRate/int64(f.sampleRate))*2+2)
+	s := newPCMStream(bytes.NewReader(data), f, int64(len(data)))
+	for {
+		block, err := s.Next()
+		if err != nil { // io.EOF: a bytes.Reader fails no other way
+			return out
+		}
+		out = append(out, block...)
+	}
+}
+
+// decodeFrames decodes whole interleaved frames in format f to mono -1..1,
+// averaging the channels.
+func decodeFrames(data []byte, f wavFormat) []float64 {
+	width := f.bits / 8
+	frameSize := width * f.channels
+	mono := make([]float64, len(data)/frameSize)
+	for i := range mono {
+		var sum float64
+		for c := range f.channels {
+			sum += decodeSample(data[i*frameSize+c*width:], f.tag, f.bits)
+		}
+		mono[i] = sum / float64(f.channels)
+	}
+	return mono
+}
+
+// decodeSample reads one sample as -1..1.
+func decodeSample(b []byte, tag uint16, bits int) float64 {
+	if tag == wavFormatFloat {
+		if bits == 64 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+	switch bits {
+	case 8: // the one unsigned width
+		return (float64(b[0]) - 128) / 128
+	case 16:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case 24:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float64(v) / (1 << 23)
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	}
+}
+
+// floatToPCM16 converts -1..1 samples to signed-16-bit little-endian PCM,
+// clipping anything outside the range.
+func floatToPCM16(x []float64) []byte {
+	pcm := make([]byte, len(x)*2)
+	for i, v := range x {
+		s := math.Round(v * 32768)
+		s = max(-32768, min(32767, s))
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(s)))
+	}
+	return pcm
+}
+
+// Resampler design: a Kaiser-windowed sinc, tabulated finely and linearly
+// interpolated so any pair of rates works without a rational polyphase
+// decomposition. The cutoff sits just under the lower Nyquist so downsampling
+// can't alias; 32 zero crossings and beta 9 give ~90 dB of stopband, reached by
+// 8 kHz when converting to 16 kHz (flat to ~7 kHz, well past speech).
+const (
+	sincZeroCrossings = 32
+	sincCutoff        = 0.92 // fraction of the lower Nyquist frequency
+	sincKaiserBeta    = 9.0
+	sincTableDensity  = 512 // table entries per zero crossing
+)
+
+// sincTable is built on first use: most runs never read a file WAV.
+var sincTable = sync.OnceValue(buildSincTable)
+
+// buildSincTable tabulates the windowed sinc on [0, sincZeroCrossings] zero
+// crossings (it is symmetric); one extra entry keeps the interpolation in range.
+func buildSincTable() []float64 {
+	n := sincZeroCrossings * sincTableDensity
+	table := make([]float64, n+2)
+	norm := besselI0(sincKaiserBeta)
+	for i := range n + 1 {
+		x := float64(i) / sincTableDensity // in zero crossings
+		r := x / sincZeroCrossings
+		w := besselI0(sincKaiserBeta*math.Sqrt(1-r*r)) / norm
+		s := 1.0
+		if x != 0 {
+			s = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		table[i] = s * w
+	}
+	return table
+}
+
+// besselI0 is the zeroth-order modified Bessel function of the first kind,
+// by its power series (converges quickly for the betas a Kaiser window uses).
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > 1e-12*sum; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+	}
+	return sum
+}
+
+// monoConverter takes mono -1..1 blocks at one rate to 16 kHz signed-16-bit
+// PCM, a block at a time; the decoders all end up here.
+type monoConverter struct {
+	rs *resampler // nil at the target rate
+}
+
+func newMonoConverter(rate int) monoConverter {
+	if rate == targetRate {
+		return monoConverter{}
+	}
+	return monoConverter{rs: newResampler(rate, targetRate)}
+}
+
+// push converts mono and returns the PCM it completes; final flushes the rest.
+func (c monoConverter) push(mono []float64, final bool) []byte {
+	if c.rs != nil {
+		mono = c.rs.push(mono, final)
+	}
+	return floatToPCM16(mono)
+}
+
+// resampler converts mono samples from one rate to another with a
+// band-limited (windowed sinc) interpolator, over a stream: input arrives in
+// pieces, and each push returns the output samples whose kernel it completed.
+// The result is the same as resampling the whole signal at once.
+type resampler struct {
+	ratio     float64
+	step      float64 // kernel zero crossings per input sample
+	halfWidth float64 // kernel half-width, in input samples
+	table     []float64
+
+	buf   []float64 // input from absolute index base on
+	base  int
+	total int // input samples pushed
+	n     int // next output sample
+}
+
+func newResampler(from, to int) *resampler {
+	ratio := float64(to) / float64(from)
+	// Cutoff in cycles per input sample; when downsampling it drops with the
+	// output rate, stretching the kernel over more input samples.
+	fc := 0.5 * sincCutoff * min(1, ratio)
+	// One zero crossing of the kernel is 1/(2fc) input samples.
+	step := 2 * fc
+	return &resampler{
+		ratio:     ratio,
+		step:      step,
+		halfWidth: float64(sincZeroCrossings) / step,
+		table:     sincTable(),
+	}
+}
+
+// push appends x and returns the output it completes. final marks the end of
+// the input: the rest of the output is flushed, the kernel running off the end.
+func (r *resampler) push(x []float64, final bool) []float64 {
+	r.buf = append(r.buf, x...)
+	r.total += len(x)
+	end := int(math.Floor(float64(r.total) * r.ratio))
+	var out []float64
+	for ; !final || r.n < end; r.n++ {
+		t := float64(r.n) / r.ratio // output sample n, in input-sample time
+		hi := int(math.Floor(t + r.halfWidth))
+		if !final && hi >= r.total {
+			break
+		}
+		lo := max(0, int(math.Ceil(t-r.halfWidth)))
+		hi = min(r.total-1, hi)
+		var acc float64
+		for k := lo; k <= hi; k++ {
+			pos := math.Abs(float64(k)-t) * r.step * sincTableDensity
+			i := int(pos)
+			if i >= len(r.table)-1 {
+				continue
+			}
+			frac := pos - float64(i)
+			acc += r.buf[k-r.base] * (r.table[i] + frac*(r.table[i+1]-r.table[i]))
+		}
+		out = append(out, acc*r.step)
+	}
+	// Drop the input no later output sample can reach.
+	if drop := int(math.Ceil(float64(r.n)/r.ratio-r.halfWidth)) - r.base; drop > 0 {
+		drop = min(drop, len(r.buf))
+		r.buf = append(r.buf[:0], r.buf[drop:]...)
+		r.base += drop
+	}
+	return out
+}
@@ -0,0 +1,228 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// sweep is a linear chirp from f0 to f1 Hz over dur seconds at rate, amplitude
+// 0.5 — evaluated analytically, so any rate's version is the same signal.
+func sweep(rate int, dur, f0, f1 float64) []float64 {
+	x := make([]float64, int(dur*float64(rate)))
+	for i := range x {
+		t := float64(i) / float64(rate)
+		x[i] = 0.5 * math.Sin(2*math.Pi*(f0*t+(f1-f0)*t*t/(2*dur)))
+	}
+	return x
+}
+
+// makeWAV encodes per-channel samples as a WAV in the given encoding. tag is
+// wavFormatPCM or wavFormatFloat; extensible wraps it in a
+// WAVE_FORMAT_EXTENSIBLE header.
+func makeWAV(tag uint16, bits, rate int, extensible bool, channels ...[]float64) []byte {
+	width := bits / 8
+	var data bytes.Buffer
+	sample := make([]byte, width)
+	for i := range channels[0] {
+		for _, ch := range channels {
+			v := ch[i]
+			switch {
+			case tag == wavFormatFloat && bits == 64:
+				binary.LittleEndian.PutUint64(sample, math.Float64bits(v))
+			case tag == wavFormatFloat:
+				binary.LittleEndian.PutUint32(sample, math.Float32bits(float32(v)))
+			case bits == 8:
+				sample[0] = byte(math.Round(v*127) + 128)
+			case bits == 16:
+				binary.LittleEndian.PutUint16(sample, uint16(int16(math.Round(v*32767))))
+			case bits == 24:
+				s := int32(math.Round(v * 8388607))
+				sample[0], sample[1], sample[2] = byte(s), byte(s>>8), byte(s>>16)
+			default:
+				binary.LittleEndian.PutUint32(sample, uint32(int32(math.Round(v*2147483647))))
+			}
+			data.Write(sample)
+		}
+	}
+
+	var fmtChunk bytes.Buffer
+	le := func(v any) { binary.Write(&fmtChunk, binary.LittleEndian, v) }
+	if extensible {
+		le(uint16(wavFormatExtensible))
+	} else {
+		le(tag)
+	}
+	le(uint16(len(channels)))
+	le(uint32(rate))
+	le(uint32(rate * width * len(channels)))
+	le(uint16(width * len(channels)))
+	le(uint16(bits))
+	if extensible {
+		le(uint16(22))   // cbSize
+		le(uint16(bits)) // valid bits
+		le(uint32(0))    // channel mask
+		le(tag)          // SubFormat GUID: format code + fixed suffix
+		fmtChunk.Write([]byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71})
+	}
+
+	var wav bytes.Buffer
+	chunk := func(id string, body []byte) {
+		wav.WriteString(id)
+		binary.Write(&wav, binary.LittleEndian, uint32(len(body)))
+		wav.Write(body)
+	}
+	wav.WriteString("RIFF")
+	binary.Write(&wav, binary.LittleEndian, uint32(4+8+fmtChunk.Len()+8+data.Len()))
+	wav.WriteString("WAVE")
+	chunk("fmt ", fmtChunk.Bytes())
+	chunk("data", data.Bytes())
+	return wav.Bytes()
+}
+
+func pcmFloats(pcm []byte) []float64 {
+	x := make([]float64, len(pcm)/2)
+	for i := range x {
+		x[i] = float64(int16(binary.LittleEndian.Uint16(pcm[i*2:]))) / 32768
+	}
+	return x
+}
+
+// snrDB compares got against want, skipping edge samples at both ends where
+// the resampler's kernel runs off the signal.
+func snrDB(got, want []float64, edge int) float64 {
+	var sig, noise float64
+	for i := edge; i < min(len(got), len(want))-edge; i++ {
+		sig += want[i] * want[i]
+		d := got[i] - want[i]
+		noise += d * d
+	}
+	return 10 * math.Log10(sig/noise)
+}
+
+// resample converts a whole signal at once, the reference the block-wise
+// paths must match.
+func resample(x []float64, from, to int) []float64 {
+	if from == to || len(x) == 0 {
+		return x
+	}
+	return newResampler(from, to).push(x, true)
+}
+
+func rms(x []float64) float64 {
+	var sum float64
+	for _, v := range x {
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(len(x)))
+}
+
+func TestWAVToPCMPassesTargetFormatThrough(t *testing.T) {
+	wav := makeWAV(wavFormatPCM, 16, 16000, false, sweep(16000, 0.5, 100, 6000))
+	pcm, err := WAVToPCM(wav)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pcm, wav[WAVHeaderSize:]) {
+		t.Error("16 kHz mono 16-bit input was modified")
+	}
+}
+
+func TestWAVToPCMConvertsSweep(t *testing.T) {
+	const dur = 1.0
+	cases := []struct {
+		name       string
+		tag        uint16
+		bits, rate int
+		channels   int
+		extensible bool
+		f1         float64 // sweep top, inside the lower Nyquist
+		minSNR     float64
+	}{
+		{"44.1k stereo 16-bit", wavFormatPCM, 16, 44100, 2, false, 6000, 50},
+		{"48k mono 24-bit", wavFormatPCM, 24, 48000, 1, false, 6000, 50},
+		{"22.05k stereo 8-bit", wavFormatPCM, 8, 22050, 2, false, 6000, 30},
+		{"8k mono 32-bit (upsample)", wavFormatPCM, 32, 8000, 1, false, 3000, 50},
+		{"44.1k 4ch float32 extensible", wavFormatFloat, 32, 44100, 4, true, 6000, 50},
+		{"96k stereo float64", wavFormatFloat, 64, 96000, 2, false, 6000, 50},
+		{"32k stereo 24-bit extensible", wavFormatPCM, 24, 32000, 2, true, 6000, 50},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			src := sweep(c.rate, dur, 100, c.f1)
+			chans := make([][]float64, c.channels)
+			for i := range chans {
+				chans[i] = src
+			}
+			pcm, err := WAVToPCM(makeWAV(c.tag, c.bits, c.rate, c.extensible, chans...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := pcmFloats(pcm)
+			if wantLen := int(dur * targetRate); len(got) != wantLen {
+				t.Fatalf("len = %d samples, want %d", len(got), wantLen)
+			}
+			want := sweep(targetRate, dur, 100, c.f1)
+			if snr := snrDB(got, want, targetRate/20); snr < c.minSNR {
+				t.Errorf("SNR = %.1f dB, want >= %.0f dB", snr, c.minSNR)
+			}
+		})
+	}
+}
+
+// TestToTargetPCMBlockwise: converting a block at a time must give exactly
+// what converting the whole signal at once does.
+func TestToTargetPCMBlockwise(t *testing.T) {
+	f := wavFormat{tag: wavFormatPCM, channels: 2, sampleRate: 44100, bits: 16}
+	src := sweep(44100, 1.3, 100, 6000)
+	wav := makeWAV(f.tag, f.bits, f.sampleRate, false, src, src)
+	data := wav[WAVHeaderSize : len(wav)-1] // and a trailing partial frame
+	want := floatToPCM16(resample(decodeFrames(data, f), f.sampleRate, targetRate))
+	if got := toTargetPCM(data, f); !bytes.Equal(got, want) {
+		t.Errorf("blockwise: %d bytes, whole: %d bytes, or the samples differ", len(got), len(want))
+	}
+}
+
+// TestResampleRejectsAliases: content above the new Nyquist must be filtered
+// out, not folded back into the speech band. The edges are skipped: the sweep
+// starting and stopping abruptly is itself broadband.
+func TestResampleRejectsAliases(t *testing.T) {
+	src := sweep(44100, 1, 8000, 20000)
+	got := resample(src, 44100, targetRate)
+	edge := targetRate / 20
+	if ratio := 20 * math.Log10(rms(got[edge:len(got)-edge])/rms(src)); ratio > -80 {
+		t.Errorf("aliased energy = %.1f dB relative to input, want <= -80 dB", ratio)
+	}
+}
+
+func TestWAVToPCMDownmixAverages(t *testing.T) {
+	left := sweep(48000, 0.5, 100, 6000)
+	right := make([]float64, len(left))
+	for i, v := range left {
+		right[i] = -v // out of phase: the mono mix cancels
+	}
+	pcm, err := WAVToPCM(makeWAV(wavFormatPCM, 16, 48000, false, left, right))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak := slicesMaxAbs(pcmFloats(pcm)); peak > 1e-3 {
+		t.Errorf("out-of-phase stereo mixed to peak %.4f, want ~0", peak)
+	}
+}
+
+func TestWAVToPCMRejectsCompressed(t *testing.T) {
+	wav := makeWAV(wavFormatPCM, 16, 16000, false, make([]float64, 10))
+	binary.LittleEndian.PutUint16(wav[20:22], 2) // MS ADPCM
+	if _, err := WAVToPCM(wav); err == nil {
+		t.Error("ADPCM WAV: want error")
+	}
+}
+
+func slicesMaxAbs(x []float64) float64 {
+	var m float64
+	for _, v := range x {
+		m = max(m, math.Abs(v))
+	}
+	return m
+}
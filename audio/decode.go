@@ -0,0 +1,160 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hajimehoshi/go-mp3"
+	"github.com/mewkiz/flac"
+)
+
+// DecodeToPCM decodes a WAV, MP3 or FLAC file to 16 kHz mono signed-16-bit
+// little-endian PCM, the format the local engines consume. format is the
+// transcriber's name for it: "wav", "mp3" or "flac".
+//
+// Use case: -transcribe and the fallback chain handing a compressed file (a
+// memo, or a saved samples/*/audio.mp3 from a cloud provider) to a local
+// engine. Both decoders are pure Go, so this works in every build.
+func DecodeToPCM(data []byte, format string) ([]byte, error) {
+	switch format {
+	case "wav":
+		return WAVToPCM(data)
+	case "mp3":
+		return mp3ToPCM(data)
+	case "flac":
+		return flacToPCM(data)
+	default:
+		return nil, fmt.Errorf("unsupported audio format %q", format)
+	}
+}
+
+// Duration reports how long a WAV, MP3 or FLAC file plays, without decoding
+// it: from the WAV header, the MP3 frame headers, or the FLAC stream info —
+// or, when a FLAC (like zee's own) leaves its length unset, by walking its
+// frames one at a time.
+//
+// Use case: deciding whether a -transcribe file needs chunking before paying
+// for a full decode, which only the chunked path needs.
+func Duration(data []byte, format string) (time.Duration, error) {
+	seconds := func(samples int64, rate int) time.Duration {
+		return time.Duration(samples) * time.Second / time.Duration(rate)
+	}
+	switch format {
+	case "wav":
+		r := bytes.NewReader(data)
+		s, err := NewWAVStream(r)
+		if err != nil {
+			return 0, err
+		}
+		n := int64(r.Len())
+		if s.left >= 0 {
+			n = min(n, s.left)
+		}
+		return seconds(n/int64(s.f.bits/8*s.f.channels), s.f.sampleRate), nil
+	case "mp3":
+		dec, err := mp3.NewDecoder(bytes.NewReader(data))
+		if err != nil {
+			return 0, fmt.Errorf("mp3: %w", err)
+		}
+		return seconds(dec.Length()/4, dec.SampleRate()), nil
+	case "flac":
+		stream, err := flac.New(bytes.NewReader(data))
+		if err != nil {
+			return 0, fmt.Errorf("flac: %w", err)
+		}
+		defer stream.Close()
+		rate := int(stream.Info.SampleRate)
+		if rate == 0 {
+			return 0, fmt.Errorf("flac: bad stream info: %d Hz", rate)
+		}
+		if n := stream.Info.NSamples; n > 0 {
+			return seconds(int64(n), rate), nil
+		}
+		var n int64
+		for {
+			fr, err := stream.ParseNext()
+			if err == io.EOF || n > 0 && errors.Is(err, io.ErrUnexpectedEOF) {
+				return seconds(n, rate), nil
+			}
+			if err != nil {
+				return 0, fmt.Errorf("flac: %w", err)
+			}
+			n += int64(fr.BlockSize)
+		}
+	default:
+		return 0, fmt.Errorf("unsupported audio format %q", format)
+	}
+}
+
+// mp3ToPCM decodes MPEG-1/2/2.5 Layer III. go-mp3 always yields interleaved
+// stereo s16le at the stream's rate (mono is duplicated), which a PCMStream
+// folds back down a block at a time as it is decoded.
+func mp3ToPCM(data []byte) ([]byte, error) {
+	dec, err := mp3.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("mp3: %w", err)
+	}
+	f := wavFormat{tag: wavFormatPCM, channels: 2, sampleRate: dec.SampleRate(), bits: 16}
+	s := newPCMStream(dec, f, -1) // a truncated last frame ends it like EOF
+	var pcm []byte
+	for {
+		block, err := s.Next()
+		if err == io.EOF {
+			return pcm, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mp3: %w", err)
+		}
+		pcm = append(pcm, block...)
+	}
+}
+
+// flacToPCM decodes FLAC a frame at a time, each downmixed and converted
+// before the next is parsed.
+func flacToPCM(data []byte) ([]byte, error) {
+	stream, err := flac.New(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("flac: %w", err)
+	}
+	defer stream.Close()
+
+	info := stream.Info
+	if info.SampleRate == 0 || info.NChannels == 0 {
+		return nil, fmt.Errorf("flac: bad stream info: %d ch %d Hz", info.NChannels, info.SampleRate)
+	}
+	scale := float64(int64(1) << (info.BitsPerSample - 1))
+	conv := newMonoConverter(int(info.SampleRate))
+	var (
+		pcm     []byte
+		mono    []float64
+		decoded bool
+	)
+	for {
+		fr, err := stream.ParseNext()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A truncated final frame (an interrupted save) still leaves the
+			// decoded audio before it usable.
+			if decoded && errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, fmt.Errorf("flac: %w", err)
+		}
+		mono = mono[:0]
+		for i := range int(fr.BlockSize) {
+			var sum float64
+			for _, sub := range fr.Subframes {
+				sum += float64(sub.Samples[i])
+			}
+			mono = append(mono, sum/float64(len(fr.Subframes))/scale)
+		}
+		pcm = append(pcm, conv.push(mono, false)...)
+		decoded = true
+	}
+	return append(pcm, conv.push(nil, true)...), nil
+}
@@ -0,0 +1,185 @@
+package audio
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"zee/encoder"
+	"zee/internal/mp3"
+)
+
+func toInt16(x []float64) []int16 {
+	s := make([]int16, len(x))
+	for i, v := range x {
+		s[i] = int16(math.Round(v * 32767))
+	}
+	return s
+}
+
+// toneLevel is the amplitude of the freq Hz component of x (Goertzel).
+func toneLevel(x []float64, rate int, freq float64) float64 {
+	w := 2 * math.Pi * freq / float64(rate)
+	var s1, s2 float64
+	for _, v := range x {
+		s1, s2 = v+2*math.Cos(w)*s1-s2, s1
+	}
+	power := s1*s1 + s2*s2 - 2*math.Cos(w)*s1*s2
+	return 2 * math.Sqrt(power) / float64(len(x))
+}
+
+// quietTone is a 440 Hz tone at amplitude 0.1, leaving the encoder headroom.
+func quietTone(rate int) []int16 {
+	x := sweep(rate, 1, 440, 440)
+	for i := range x {
+		x[i] *= 0.2
+	}
+	return toInt16(x)
+}
+
+// checkTone asserts decoded PCM is ~1s of a clean 440 Hz tone: nearly all of
+// its energy at 440 Hz. Lossy codecs add delay and shave the ends, so only the
+// middle is measured; the absolute level is the encoder's business, not the
+// decoder's.
+func checkTone(t *testing.T, pcm []byte) {
+	t.Helper()
+	got := pcmFloats(pcm)
+	if len(got) < targetRate*9/10 || len(got) > targetRate*11/10 {
+		t.Fatalf("decoded %d samples, want ~%d", len(got), targetRate)
+	}
+	mid := got[targetRate/4 : 3*targetRate/4]
+	level := toneLevel(mid, targetRate, 440)
+	if purity := level / math.Sqrt2 / rms(mid); purity < 0.95 {
+		t.Errorf("440 Hz carries %.0f%% of the signal, want >= 95%%", 100*purity)
+	}
+	if stray := toneLevel(mid, targetRate, 1500); stray > 0.01*level {
+		t.Errorf("1500 Hz level = %.4f against %.4f at 440 Hz, want ~0", stray, level)
+	}
+}
+
+func TestDecodeMP3LowSampleRate(t *testing.T) {
+	// The app's own recordings: MPEG-2 Layer III, 16 kHz mono.
+	enc, _ := encoder.NewMp3(64)
+	enc.EncodeBlock(quietTone(targetRate))
+	enc.Close()
+
+	pcm, err := DecodeToPCM(enc.Bytes(), "mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTone(t, pcm)
+}
+
+func TestDecodeMP3StereoResampled(t *testing.T) {
+	// A typical download: MPEG-1 Layer III, 44.1 kHz stereo.
+	tone := quietTone(44100)
+	interleaved := make([]int16, 0, 2*len(tone))
+	for _, s := range tone {
+		interleaved = append(interleaved, s, s)
+	}
+	var buf bytes.Buffer
+	if err := mp3.NewEncoder(44100, 2, 128).Write(&buf, interleaved); err != nil {
+		t.Fatal(err)
+	}
+
+	pcm, err := DecodeToPCM(buf.Bytes(), "mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTone(t, pcm)
+}
+
+func TestDecodeFLACIsLossless(t *testing.T) {
+	samples := toInt16(sweep(targetRate, 1, 100, 6000))
+	enc, err := encoder.NewFlac()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for len(samples) > 0 {
+		n := min(len(samples), encoder.BlockSize)
+		enc.EncodeBlock(samples[:n])
+		samples = samples[n:]
+	}
+	enc.Close()
+
+	pcm, err := DecodeToPCM(enc.Bytes(), "flac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := toInt16(sweep(targetRate, 1, 100, 6000))
+	got := pcmFloats(pcm)
+	if len(got) != len(want) {
+		t.Fatalf("decoded %d samples, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if s := int16(math.Round(got[i] * 32768)); s != want[i] {
+			t.Fatalf("sample %d = %d, want %d", i, s, want[i])
+		}
+	}
+}
+
+func TestDecodeFLACTruncated(t *testing.T) {
+	enc, err := encoder.NewFlac()
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := toInt16(sweep(targetRate, 1, 100, 6000))
+	for len(samples) > 0 {
+		n := min(len(samples), encoder.BlockSize)
+		enc.EncodeBlock(samples[:n])
+		samples = samples[n:]
+	}
+	enc.Close()
+	data := enc.Bytes()
+
+	pcm, err := DecodeToPCM(data[:len(data)*2/3], "flac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(pcm) / 2; n == 0 || n >= targetRate {
+		t.Errorf("decoded %d samples of an interrupted save, want the part before the cut", n)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tone := quietTone(44100)
+	var mp3Buf bytes.Buffer
+	if err := mp3.NewEncoder(44100, 1, 128).Write(&mp3Buf, tone); err != nil {
+		t.Fatal(err)
+	}
+	flacEnc, err := encoder.NewFlac() // leaves the stream's length unset
+	if err != nil {
+		t.Fatal(err)
+	}
+	flacEnc.EncodeBlock(quietTone(targetRate)[:targetRate/2])
+	flacEnc.Close()
+	wav := makeWAV(wavFormatPCM, 24, 48000, false, sweep(48000, 1.5, 100, 6000))
+
+	for _, c := range []struct {
+		format string
+		data   []byte
+		want   time.Duration
+	}{
+		{"wav", wav, 1500 * time.Millisecond},
+		{"mp3", mp3Buf.Bytes(), time.Second},
+		{"flac", flacEnc.Bytes(), 500 * time.Millisecond},
+	} {
+		got, err := Duration(c.data, c.format)
+		if err != nil {
+			t.Errorf("%s: %v", c.format, err)
+			continue
+		}
+		if d := got - c.want; d < 0 || d > 100*time.Millisecond {
+			t.Errorf("%s: Duration = %v, want %v (plus codec padding)", c.format, got, c.want)
+		}
+	}
+}
+
+func TestDecodeToPCMRejectsGarbage(t *testing.T) {
+	for _, format := range []string{"mp3", "flac", "wav", "ogg"} {
+		if _, err := DecodeToPCM([]byte("definitely not audio"), format); err == nil {
+			t.Errorf("%s: want error", format)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// PCMStream converts a WAV or raw PCM stream to 16 kHz mono signed-16-bit
+// PCM as it arrives, a block at a time — WAVToPCM for input that has no end
+// yet.
+//
+// Use case: `zee transcribe -` reading `arecord` or `ffmpeg` from stdin, where
+// a streaming provider should hear the audio while it is still being
+// produced. A WAV written to a pipe can't know its length, so a data size of 0
+// or 0xFFFFFFFF-ish (what arecord and ffmpeg write) means "until EOF".
+type PCMStream struct {
+	r       io.Reader
+	f       wavFormat
+	conv    monoConverter
+	left    int64  // data bytes still to read; -1 = until EOF
+	partial []byte // bytes of an incomplete frame
+	done    bool
+}
+
+// streamBlockMs is how much input one Next reads.
+const streamBlockMs = 100
+
+// NewWAVStream reads a WAV header from r, up to the start of its data chunk.
+func NewWAVStream(r io.Reader) (*PCMStream, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("reading WAV header: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a RIFF/WAVE stream")
+	}
+	var fmtChunk []byte
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, fmt.Errorf("no data chunk: %w", err)
+		}
+		id, size := string(hdr[0:4]), int64(binary.LittleEndian.Uint32(hdr[4:8]))
+		if id == "data" {
+			if fmtChunk == nil {
+				return nil, fmt.Errorf("no fmt chunk before data")
+			}
+			f, err := parseWAVFormat(fmtChunk)
+			if err != nil {
+				return nil, err
+			}
+			if size == 0 || size >= 0x7FFFF000 {
+				size = -1
+			}
+			return newPCMStream(r, f, size), nil
+		}
+		body := size + size%2 // chunks are word-aligned
+		if id == "fmt " {
+			fmtChunk = make([]byte, body)
+			if _, err := io.ReadFull(r, fmtChunk); err != nil {
+				return nil, fmt.Errorf("reading fmt chunk: %w", err)
+			}
+			continue
+		}
+		if _, err := io.CopyN(io.Discard, r, body); err != nil {
+			return nil, fmt.Errorf("skipping %q chunk: %w", id, err)
+		}
+	}
+}
+
+// NewRawStream reads headerless signed-16-bit little-endian PCM at rate Hz
+// with the given channel count, until EOF.
+func NewRawStream(r io.Reader, rate, channels int) (*PCMStream, error) {
+	if rate < 1 || channels < 1 {
+		return nil, fmt.Errorf("bad raw PCM format: %d ch %d Hz", channels, rate)
+	}
+	return newPCMStream(r, wavFormat{tag: wavFormatPCM, channels: channels, sampleRate: rate, bits: 16}, -1), nil
+}
+
+func newPCMStream(r io.Reader, f wavFormat, size int64) *PCMStream {
+	return &PCMStream{r: r, f: f, conv: newMonoConverter(f.sampleRate), left: size}
+}
+
+// Next returns the next block of converted PCM, blocking until about
+// streamBlockMs of input has arrived or the stream ends; io.EOF after the last
+// block.
+func (s *PCMStream) Next() ([]byte, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	frameSize := s.f.bits / 8 * s.f.channels
+	want := int64(s.f.sampleRate * streamBlockMs / 1000 * frameSize)
+	if s.left >= 0 {
+		want = min(want, s.left)
+	}
+	buf := make([]byte, want)
+	n, err := io.ReadFull(s.r, buf)
+	if s.left >= 0 {
+		s.left -= int64(n)
+	}
+	final := s.left == 0 || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	if err != nil && !final {
+		return nil, err
+	}
+	data := append(s.partial, buf[:n]...)
+	whole := len(data) / frameSize * frameSize
+	s.partial = append([]byte(nil), data[whole:]...)
+	data = data[:whole]
+	s.done = final
+
+	var out []byte
+	if s.f.isTarget() {
+		out = data
+	} else {
+		out = s.conv.push(decodeFrames(data, s.f), final)
+	}
+	if len(out) == 0 && final {
+		return nil, io.EOF
+	}
+	return out, nil
+}
@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func readStream(t *testing.T, s *PCMStream) []byte {
+	t.Helper()
+	var out []byte
+	for {
+		block, err := s.Next()
+		if errors.Is(err, io.EOF) {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, block...)
+	}
+}
+
+// TestWAVStreamMatchesWAVToPCM: converting block by block, from a reader that
+// trickles a few bytes at a time, gives exactly the whole-file conversion.
+func TestWAVStreamMatchesWAVToPCM(t *testing.T) {
+	src := sweep(44100, 1, 100, 6000)
+	wav := makeWAV(wavFormatPCM, 16, 44100, false, src, src)
+	want, err := WAVToPCM(wav)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewWAVStream(iotest.HalfReader(bytes.NewReader(wav)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readStream(t, s); !bytes.Equal(got, want) {
+		t.Errorf("stream gave %d bytes, differing from WAVToPCM's %d", len(got), len(want))
+	}
+}
+
+func TestWAVStreamUnknownLength(t *testing.T) {
+	// What arecord writes to a pipe: a placeholder data size.
+	wav := makeWAV(wavFormatPCM, 16, 16000, false, sweep(16000, 0.5, 100, 6000))
+	binary.LittleEndian.PutUint32(wav[40:44], 0x7FFFFFFF)
+	s, err := NewWAVStream(bytes.NewReader(wav))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readStream(t, s); !bytes.Equal(got, wav[WAVHeaderSize:]) {
+		t.Errorf("got %d bytes, want the %d after the header", len(got), len(wav)-WAVHeaderSize)
+	}
+}
+
+func TestRawStreamResamples(t *testing.T) {
+	wav := makeWAV(wavFormatPCM, 16, 8000, false, sweep(8000, 1, 100, 3000))
+	s, err := NewRawStream(bytes.NewReader(wav[WAVHeaderSize:]), 8000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := pcmFloats(readStream(t, s))
+	if len(got) != targetRate {
+		t.Fatalf("len = %d samples, want %d", len(got), targetRate)
+	}
+	if snr := snrDB(got, sweep(targetRate, 1, 100, 3000), targetRate/20); snr < 50 {
+		t.Errorf("SNR = %.1f dB, want >= 50 dB", snr)
+	}
+}
+
+func TestWAVStreamRejectsGarbage(t *testing.T) {
+	if _, err := NewWAVStream(bytes.NewReader([]byte("definitely not audio"))); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// `zee transcribe` is -transcribe for folders of hundreds of memos: inputs are
+// directories (walked recursively) and globs as well as files, several files
+// are in flight at once, a failure is recorded instead of aborting the run,
+// and every finished file is appended to a JSONL manifest so an interrupted
+// run picks up where it stopped.
+
+// batchAudioExts are the extensions transcribeFile accepts.
+var batchAudioExts = []string{".wav", ".mp3", ".flac"}
+
+// batchManifestName is the manifest's default file name, in -outdir or the
+// current directory.
+const batchManifestName = "zee-manifest.jsonl"
+
+type batchOptions struct {
+	format   string // an outputFormats key
+	outDir   string
+	jobs     int
+	manifest string // "" = batchManifestName in outDir or the current directory
+	local    bool   // local engine: one file at a time
+}
+
+// manifestEntry is one line of the manifest: a file's outcome. A file may
+// appear more than once (failed, then done on a rerun); the last line wins.
+type manifestEntry struct {
+	File      string  `json:"file"`
+	Status    string  `json:"status"` // "done" or "failed"
+	Format    string  `json:"format,omitempty"`
+	Output    string  `json:"output,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	AudioS    float64 `json:"audio_s,omitempty"`
+	ElapsedMs float64 `json:"elapsed_ms"`
+	Provider  string  `json:"provider"`
+	Model     string  `json:"model,omitempty"`
+	Time      string  `json:"time"`
+}
+
+// expandInputs resolves the command line into audio files, in order and
+// without duplicates: directories are walked for batchAudioExts, arguments
+// with glob metacharacters are expanded, anything else is taken as a file.
+// Paths are made absolute so the manifest matches across working directories.
+func expandInputs(args []string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	add := func(path string) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	isAudio := func(path string) bool {
+		return slices.Contains(batchAudioExts, strings.ToLower(filepath.Ext(path)))
+	}
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "*?[") {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%s: no matches", arg)
+			}
+			for _, m := range matches {
+				if isAudio(m) {
+					add(m)
+				}
+			}
+			continue
+		}
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			add(arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && isAudio(path) {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// loadManifest returns the files a previous run finished in format, with the
+// sidecar where this run would write it: a file done as .txt, or into another
+// -outdir, is still to do as .srt. A missing manifest is an empty one; a torn
+// last line (the run was killed mid-write) is skipped.
+func loadManifest(path, format, outDir string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	done := map[string]bool{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e manifestEntry
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			continue
+		}
+		done[e.File] = e.Status == "done" && e.Format == format &&
+			e.Output == sidecarPath(e.File, format, outDir)
+	}
+	return done, sc.Err()
+}
+
+// sidecarCollision finds two inputs whose transcripts would land on the same
+// path — same-named recordings from different folders under one -outdir —
+// and returns them with that path, or "" when every sidecar is distinct.
+// Checked before anything is transcribed: one would overwrite the other, and
+// the manifest would count both as done.
+func sidecarCollision(files []string, format, outDir string) (a, b, out string) {
+	seen := make(map[string]string, len(files))
+	for _, f := range files {
+		p := sidecarPath(f, format, outDir)
+		if prev, ok := seen[p]; ok {
+			return prev, f, p
+		}
+		seen[p] = f
+	}
+	return "", "", ""
+}
+
+// runBatch transcribes every input with the loaded engine, writing each
+// transcript as a sidecar (text included, as .txt), and returns the exit code:
+// 1 if any file failed. Progress goes to out, one line per file; errors that
+// stop the run, or the manifest, go to errOut.
+func runBatch(args []string, opt batchOptions, out, errOut io.Writer) int {
+	files, err := expandInputs(args)
+	if err != nil {
+		fmt.Fprintf(errOut, "zee transcribe: %v\n", err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(errOut, "zee transcribe: no .wav, .mp3 or .flac files found")
+		return 1
+	}
+	if a, b, out := sidecarCollision(files, opt.format, opt.outDir); out != "" {
+		fmt.Fprintf(errOut, "zee transcribe: %s and %s would both be written to %s; transcribe them into different -outdir folders\n", a, b, out)
+		return 1
+	}
+	manifestPath := opt.manifest
+	if manifestPath == "" {
+		manifestPath = filepath.Join(opt.outDir, batchManifestName)
+	}
+	done, err := loadManifest(manifestPath, opt.format, opt.outDir)
+	if err != nil {
+		fmt.Fprintf(errOut, "zee transcribe: manifest: %v\n", err)
+		return 1
+	}
+	if dir := filepath.Dir(manifestPath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fmt.Fprintf(errOut, "zee transcribe: manifest: %v\n", err)
+			return 1
+		}
+	}
+	mf, err := os.OpenFile(manifestPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(errOut, "zee transcribe: manifest: %v\n", err)
+		return 1
+	}
+	defer mf.Close()
+
+	var todo []string
+	for _, f := range files {
+		if !done[f] {
+			todo = append(todo, f)
+		}
+	}
+	skipped := len(files) - len(todo)
+	jobs := max(1, opt.jobs)
+	if opt.local {
+		jobs = 1
+	}
+	fmt.Fprintf(out, "%d files (%d already done), %d at a time; manifest %s\n",
+		len(files), skipped, jobs, manifestPath)
+
+	var (
+		mu       sync.Mutex
+		audioS   float64
+		failures []manifestEntry
+		ok       int
+	)
+	record := func(e manifestEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		line, _ := json.Marshal(e)
+		if _, err := mf.Write(append(line, '\n')); err != nil {
+			fmt.Fprintf(errOut, "zee transcribe: manifest: %v\n", err)
+		}
+		if e.Status == "done" {
+			ok++
+			audioS += e.AudioS
+			fmt.Fprintf(out, "done  %s -> %s\n", e.File, e.Output)
+		} else {
+			failures = append(failures, e)
+			fmt.Fprintf(out, "FAIL  %s: %s\n", e.File, e.Error)
+		}
+	}
+
+	start := time.Now()
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for _, f := range todo {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			record(transcribeOne(f, opt))
+		}()
+	}
+	wg.Wait()
+
+	wall := time.Since(start).Seconds()
+	fmt.Fprintf(out, "\n%d transcribed, %d skipped, %d failed: %.1fs of audio in %.1fs",
+		ok, skipped, len(failures), audioS, wall)
+	if wall > 0 && audioS > 0 {
+		fmt.Fprintf(out, " (%.1fx realtime)", audioS/wall)
+	}
+	fmt.Fprintln(out)
+	if len(failures) == 0 {
+		return 0
+	}
+	fmt.Fprintln(out, "failed:")
+	for _, e := range failures {
+		fmt.Fprintf(out, "  %s: %s\n", e.File, e.Error)
+	}
+	return 1
+}
+
+// transcribeOne transcribes and writes one file, as a manifest entry.
+func transcribeOne(file string, opt batchOptions) manifestEntry {
+	t := time.Now()
+	e := manifestEntry{
+		File:     file,
+		Format:   opt.format,
+		Provider: activeTranscriber.Name(),
+		Model:    activeTranscriber.GetModel(),
+	}
+	result, err := transcribeFile(file)
+	if err == nil {
+		e.AudioS = result.Duration
+		e.Output, err = writeSidecar(file, opt.format, opt.outDir, result)
+	}
+	e.ElapsedMs = float64(time.Since(t).Milliseconds())
+	e.Time = time.Now().Format(time.RFC3339)
+	if err != nil {
+		e.Status, e.Error = "failed", err.Error()
+	} else {
+		e.Status = "done"
+	}
+	return e
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"zee/audio"
+	"zee/transcriber"
+)
+
+// writeClips creates one-second silent WAVs (and any other named files) under
+// dir, returning their absolute paths.
+func writeClips(t *testing.T, dir string, names ...string) []string {
+	t.Helper()
+	var paths []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, audio.PCMToWAV(make([]byte, 32000)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	p := writeClips(t, dir, "a.wav", "memos/b.MP3", "memos/deep/c.flac", "memos/notes.txt", "d.wav")
+
+	got, err := expandInputs([]string{
+		filepath.Join(dir, "memos"), // walked: b, c; notes.txt ignored
+		filepath.Join(dir, "*.wav"), // a, d
+		p[0],                        // a again
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{p[1], p[2], p[0], p[4]}; !slices.Equal(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+
+	if _, err := expandInputs([]string{filepath.Join(dir, "*.ogg")}); err == nil {
+		t.Error("glob without matches: want error")
+	}
+	if _, err := expandInputs([]string{filepath.Join(dir, "missing.wav")}); err == nil {
+		t.Error("missing file: want error")
+	}
+}
+
+func TestLoadManifestLastEntryWins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "m.jsonl")
+	os.WriteFile(path, []byte(`{"file":"/a.wav","status":"failed"}
+{"file":"/b.wav","status":"done","format":"srt","output":"/b.srt"}
+{"file":"/a.wav","status":"done","format":"srt","output":"/a.srt"}
+{"file":"/b.wav","status":"failed"}
+{"file":"/d.wav","status":"done","format":"text","output":"/d.txt"}
+{"file":"/e.wav","status":"done","format":"srt","output":"/out/e.srt"}
+{"file":"/c.wav","sta`), 0644)
+	done, err := loadManifest(path, "srt", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// d was done in another format, e into another directory.
+	if !done["/a.wav"] || done["/b.wav"] || done["/c.wav"] || done["/d.wav"] || done["/e.wav"] {
+		t.Errorf("done = %v, want only /a.wav", done)
+	}
+}
+
+func TestRunBatchRefusesSidecarCollision(t *testing.T) {
+	orig := activeTranscriber
+	t.Cleanup(func() { activeTranscriber = orig })
+	activeTranscriber = transcriber.NewFake("hello", nil)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	writeClips(t, dir, "a/memo.wav", "b/memo.wav")
+
+	var stderr bytes.Buffer
+	if code := runBatch([]string{dir}, batchOptions{format: "text", outDir: out}, io.Discard, &stderr); code != 1 {
+		t.Fatalf("exit %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), filepath.Join(out, "memo.txt")) {
+		t.Errorf("stderr = %q, want the shared sidecar named", stderr.String())
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Error("outdir written despite the collision")
+	}
+
+	// Beside their inputs the two don't collide.
+	if code := runBatch([]string{dir}, batchOptions{format: "text", manifest: filepath.Join(dir, "m.jsonl")}, io.Discard, io.Discard); code != 0 {
+		t.Errorf("exit %d without -outdir, want 0", code)
+	}
+}
+
+func TestRunBatchResumes(t *testing.T) {
+	orig := activeTranscriber
+	t.Cleanup(func() { activeTranscriber = orig })
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	files := writeClips(t, dir, "one.wav", "two.wav")
+	bad := filepath.Join(dir, "notes.ogg") // named explicitly, so tried and failed
+	os.WriteFile(bad, []byte("OggS"), 0644)
+	args := []string{dir, bad}
+	opt := batchOptions{format: "text", outDir: out, jobs: 2}
+
+	// First run: the provider is down, every file fails.
+	activeTranscriber = transcriber.NewFake("", errors.New("503"))
+	var buf bytes.Buffer
+	if code := runBatch(args, opt, &buf, io.Discard); code != 1 {
+		t.Fatalf("exit %d with every file failing, want 1\n%s", code, buf.String())
+	}
+
+	// Second run: it's back; all three are retried and the two WAVs succeed.
+	activeTranscriber = transcriber.NewFake("hello", nil)
+	buf.Reset()
+	runBatch(args, opt, &buf, io.Discard)
+	for _, f := range files {
+		text, err := os.ReadFile(sidecarPath(f, "text", out))
+		if err != nil || strings.TrimSpace(string(text)) != "hello" {
+			t.Errorf("%s: sidecar %q, %v", f, text, err)
+		}
+	}
+	if !strings.Contains(buf.String(), "2 transcribed, 0 skipped, 1 failed: 2.0s of audio") {
+		t.Errorf("summary missing or wrong:\n%s", buf.String())
+	}
+
+	// Third run: only the .ogg is left to try.
+	buf.Reset()
+	runBatch(args, opt, &buf, io.Discard)
+	if !strings.Contains(buf.String(), "0 transcribed, 2 skipped, 1 failed") {
+		t.Errorf("done files were not skipped:\n%s", buf.String())
+	}
+
+	// Another format isn't done yet; the manifest is shared all the same.
+	buf.Reset()
+	runBatch(args, batchOptions{format: "srt", outDir: out, manifest: filepath.Join(out, batchManifestName)}, &buf, io.Discard)
+	if !strings.Contains(buf.String(), "2 transcribed, 0 skipped, 1 failed") {
+		t.Errorf("files done as text were skipped for srt:\n%s", buf.String())
+	}
+
+	// A run that can't start says so on errOut, not among the progress.
+	buf.Reset()
+	var errBuf bytes.Buffer
+	if code := runBatch([]string{filepath.Join(dir, "missing", "*.wav")}, opt, &buf, &errBuf); code != 1 ||
+		buf.Len() != 0 || !strings.Contains(errBuf.String(), "no matches") {
+		t.Errorf("setup error: exit %d, out %q, errOut %q", code, buf.String(), errBuf.String())
+	}
+}
@@ -0,0 +1,546 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"zee/audio"
+	"zee/encoder"
+	"zee/log"
+	"zee/transcriber"
+)
+
+// Long audio is transcribed a window at a time. Cloud endpoints cap uploads
+// (~25 MB for OpenAI and Groq) and the local engines' memory grows with clip
+// length, so neither can take an hour in one piece. Windows end at a VAD pause
+// once they pass chunkTarget, or hard at chunkMax when nobody pauses; the next
+// window starts chunkOverlap before the cut, so a word split by a hard cut is
+// heard whole by one side, and stitchText drops the copy.
+const (
+	chunkTarget   = 2 * time.Minute // past this, cut at the next pause
+	chunkMax      = 4 * time.Minute // cut here even mid-speech
+	chunkMinPause = 600 * time.Millisecond
+	chunkOverlap  = 300 * time.Millisecond
+	// chunkMaxBytes chunks a -transcribe file whatever its length: a short
+	// 48 kHz 24-bit stereo WAV is already past the upload caps.
+	chunkMaxBytes = 20 << 20
+	// chunkWorkers bounds concurrent cloud requests; local engines run one
+	// window at a time (one model, and the point is bounded memory).
+	chunkWorkers = 4
+	// chunkSeamWords is the most words an overlap can repeat.
+	chunkSeamWords = 6
+)
+
+func vadFrames(d time.Duration) int { return int(d / (vadFrameMs * time.Millisecond)) }
+
+// chunkCutter decides, one VAD frame at a time, where windows end. Lengths
+// are in frames.
+type chunkCutter struct {
+	target, max, minPause, overlap int
+
+	window  int // frames in the current window
+	silence int // current run of non-speech frames
+}
+
+func newChunkCutter() *chunkCutter {
+	return &chunkCutter{
+		target:   vadFrames(chunkTarget),
+		max:      vadFrames(chunkMax),
+		minPause: vadFrames(chunkMinPause),
+		overlap:  vadFrames(chunkOverlap),
+	}
+}
+
+// next advances by one frame and reports whether the window ends here. back
+// is how many of the latest frames fall after the cut: a pause cut lands in
+// the middle of the pause, a hard cut at the current frame.
+func (c *chunkCutter) next(speech bool) (cut, hard bool, back int) {
+	c.window++
+	if speech {
+		c.silence = 0
+	} else {
+		c.silence++
+	}
+	switch {
+	case c.silence == c.minPause && c.window >= c.target:
+		back = c.minPause / 2
+	case c.window >= c.max:
+		hard = true
+	default:
+		return false, false, 0
+	}
+	c.window = back + c.overlap
+	return true, hard, back
+}
+
+// chunkSpan is one window of a PCM buffer, in bytes. hard marks a window whose
+// start was a mid-speech cut.
+type chunkSpan struct {
+	start, end int
+	hard       bool
+}
+
+// planChunks splits 16 kHz PCM into windows. isSpeech is the VAD
+// (vadProcessor.IsSpeech); a trailing partial frame goes to the last window.
+func planChunks(pcm []byte, isSpeech func([]byte) bool, c *chunkCutter) []chunkSpan {
+	var spans []chunkSpan
+	cur := chunkSpan{}
+	for i := range len(pcm) / vadFrameBytes {
+		cut, hard, back := c.next(isSpeech(pcm[i*vadFrameBytes : (i+1)*vadFrameBytes]))
+		if !cut {
+			continue
+		}
+		at := (i + 1 - back) * vadFrameBytes
+		cur.end = at
+		spans = append(spans, cur)
+		cur = chunkSpan{start: at - c.overlap*vadFrameBytes, hard: hard}
+	}
+	cur.end = len(pcm)
+	return append(spans, cur)
+}
+
+// needsChunking reports whether a file is too long or too big for one request.
+func needsChunking(fileBytes int, dur time.Duration) bool {
+	return fileBytes > chunkMaxBytes || dur > chunkMax
+}
+
+// transcribeChunked transcribes 16 kHz PCM window by window through dt,
+// chunkWorkers at a time (one for a local engine), and stitches the windows'
+// text and segment timestamps back into one Result.
+func transcribeChunked(dt directTranscriber, pcm []byte, local bool, lang, hints string) (*transcriber.Result, error) {
+	vp, err := newVADProcessor()
+	if err != nil {
+		return nil, fmt.Errorf("VAD init: %w", err)
+	}
+	spans := planChunks(pcm, vp.IsSpeech, newChunkCutter())
+	workers := chunkWorkers
+	if local {
+		workers = 1
+	}
+	log.Info(fmt.Sprintf("chunked: %.0fs of audio in %d windows, %d at a time",
+		pcmSeconds(len(pcm)), len(spans), workers))
+
+	results := make([]*transcriber.Result, len(spans))
+	errs := make([]error, len(spans))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, sp := range spans {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			data, format, err := encodeChunk(pcm[sp.start:sp.end], local)
+			if err == nil {
+				results[i], err = dt.Transcribe(data, format, lang, hints)
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("window %d/%d at %s: %w", i+1, len(spans),
+					time.Duration(pcmSeconds(sp.start)*float64(time.Second)).Round(time.Second), err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return stitchResults(spans, results, pcmSeconds(len(pcm))), nil
+}
+
+func pcmSeconds(n int) float64 {
+	return float64(n) / float64(encoder.SampleRate*encoder.Channels*encoder.BitsPerSample/8)
+}
+
+// encodeChunk packs a window for upload: WAV for a local engine (it decodes
+// PCM anyway), FLAC for the cloud — lossless, and half the bytes.
+func encodeChunk(pcm []byte, local bool) ([]byte, string, error) {
+	if local {
+		return audio.PCMToWAV(pcm), "wav", nil
+	}
+	enc, err := encoder.NewFlac()
+	if err != nil {
+		return nil, "", err
+	}
+	block := make([]int16, 0, encoder.BlockSize)
+	for i := 0; i+1 < len(pcm); i += 2 {
+		block = append(block, int16(binary.LittleEndian.Uint16(pcm[i:])))
+		if len(block) == encoder.BlockSize || i+3 >= len(pcm) {
+			if err := enc.EncodeBlock(block); err != nil {
+				return nil, "", err
+			}
+			block = block[:0]
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, "", err
+	}
+	return enc.Bytes(), "flac", nil
+}
+
+// stitchResults joins the windows' results in order: text through stitchText,
+// segments shifted to the window's start. A segment centred inside the overlap
+// before a window's cut was already reported by the window before.
+func stitchResults(spans []chunkSpan, results []*transcriber.Result, duration float64) *transcriber.Result {
+	out := &transcriber.Result{Duration: duration}
+	for i, res := range results {
+		out.Text = stitchText(out.Text, res.Text, spans[i].hard)
+		out.InferenceMs += res.InferenceMs
+		offset := pcmSeconds(spans[i].start)
+		seam := 0.0
+		if i > 0 {
+			seam = pcmSeconds(spans[i-1].end) - offset
+		}
+		for _, seg := range res.Segments {
+			if i > 0 && (seg.Start+seg.End)/2 < seam {
+				continue
+			}
+			seg.Start += offset
+			seg.End += offset
+			out.Segments = append(out.Segments, seg)
+		}
+	}
+	return out
+}
+
+// stitchText appends next to text. After a hard cut both windows heard the
+// overlap, so the longest run of words (up to chunkSeamWords) that ends text
+// and also starts next is dropped from next, compared ignoring case and
+// punctuation. A pause cut's overlap is silence: nothing to drop, and a word
+// legitimately said twice across it must stay.
+func stitchText(text, next string, hard bool) string {
+	text, next = strings.TrimSpace(text), strings.TrimSpace(next)
+	if text == "" || next == "" {
+		return text + next
+	}
+	if hard {
+		a, b := strings.Fields(text), strings.Fields(next)
+		for k := min(chunkSeamWords, len(a), len(b)); k > 0; k-- {
+			if sameWords(a[len(a)-k:], b[:k]) {
+				next = strings.Join(b[k:], " ")
+				break
+			}
+		}
+		if next == "" {
+			return text
+		}
+	}
+	return text + " " + next
+}
+
+func sameWords(a, b []string) bool {
+	for i := range a {
+		if seamWord(a[i]) != seamWord(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func seamWord(w string) string {
+	return strings.ToLower(strings.TrimFunc(w, unicode.IsPunct))
+}
+
+// chunkedSession runs a long batch dictation as a series of sessions, one per
+// window, each closed (uploaded, or inferred locally) in the background as
+// soon as its window ends — so a long recording finishes a request-sized
+// piece at a time and release only waits for the last one. A dictation
+// shorter than chunkTarget is a single session, returned as is.
+//
+// Audio after a possible cut point is held back (pending) while a pause is
+// still short of chunkMinPause: once fed to a session it can't be taken back.
+//
+// A window that fails goes through fallback on its own, with just its audio,
+// so one bad request doesn't send the whole dictation down the chain again.
+type chunkedSession struct {
+	newSession func() (transcriber.Session, error)
+	isSpeech   func([]byte) bool
+	cutter     *chunkCutter
+	sem        chan struct{}
+	updates    chan string
+	// fallback retranscribes a failed window's audio (transcribeFallback over
+	// the configured chain); nil when there is no chain.
+	fallback func(data []byte, format string) (*fallbackHit, error)
+
+	mu      sync.Mutex
+	cur     transcriber.Session
+	buf     []byte     // partial VAD frame
+	pending []byte     // held back while the current pause may become a cut
+	tail    []byte     // last chunkOverlap fed to cur, replayed into the next window
+	hard    bool       // the current window began at a hard cut
+	orphan  *liveChunk // the current window, when its session failed to open
+	chunks  []*liveChunk
+	wg      sync.WaitGroup
+}
+
+type liveChunk struct {
+	res  transcriber.SessionResult
+	err  error
+	hard bool
+	pcm  []byte // the window's audio, kept only when it has no session
+	via  string // the fallback provider that transcribed it
+}
+
+// newChunkedSession wraps first, the dictation's already-open session;
+// newSession opens the next window's.
+func newChunkedSession(first transcriber.Session, newSession func() (transcriber.Session, error), isSpeech func([]byte) bool, local bool) *chunkedSession {
+	workers := chunkWorkers
+	if local {
+		workers = 1
+	}
+	s := &chunkedSession{
+		newSession: newSession,
+		isSpeech:   isSpeech,
+		cutter:     newChunkCutter(),
+		sem:        make(chan struct{}, workers),
+		updates:    make(chan string),
+	}
+	s.start(first)
+	return s
+}
+
+func (s *chunkedSession) start(sess transcriber.Session) {
+	s.cur = sess
+	// Batch windows don't stream, but a session must never block on an
+	// unread update.
+	go func() {
+		for range sess.Updates() {
+		}
+	}()
+}
+
+func (s *chunkedSession) Feed(pcm []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf = append(s.buf, pcm...)
+	for len(s.buf) >= vadFrameBytes {
+		frame := s.buf[:vadFrameBytes]
+		s.buf = s.buf[vadFrameBytes:]
+		speech := s.isSpeech(frame)
+		cut, hard, back := s.cutter.next(speech)
+		s.pending = append(s.pending, frame...)
+		switch {
+		case cut:
+			s.rotate(hard, back)
+		case speech || s.cutter.silence >= s.cutter.minPause:
+			s.flush()
+		}
+	}
+}
+
+func (s *chunkedSession) feed(pcm []byte) {
+	switch {
+	case len(pcm) == 0:
+	case s.cur != nil:
+		s.cur.Feed(pcm)
+	case s.orphan != nil:
+		s.orphan.pcm = append(s.orphan.pcm, pcm...)
+	}
+	s.tail = append(s.tail, pcm...)
+	if keep := s.cutter.overlap * vadFrameBytes; len(s.tail) > keep {
+		s.tail = s.tail[len(s.tail)-keep:]
+	}
+}
+
+func (s *chunkedSession) flush() {
+	s.feed(s.pending)
+	s.pending = s.pending[:0]
+}
+
+// rotate ends the current window back frames before the newest one and opens
+// the next, replaying the overlap into it.
+func (s *chunkedSession) rotate(hard bool, back int) {
+	split := max(0, len(s.pending)-back*vadFrameBytes)
+	s.feed(s.pending[:split])
+	carry := append(append([]byte(nil), s.tail...), s.pending[split:]...)
+	s.pending = s.pending[:0]
+	s.finish(false)
+
+	s.hard = hard
+	s.tail = nil
+	sess, err := s.newSession()
+	if err != nil {
+		// The window's audio is kept for fallback until the next cut; Close
+		// reports the error if that fails too.
+		s.orphan = &liveChunk{err: err, hard: hard}
+		s.chunks = append(s.chunks, s.orphan)
+		s.cur = nil
+	} else {
+		s.start(sess)
+	}
+	s.feed(carry)
+}
+
+// finish closes the current window's session in the background, and sends
+// it through fallback if it fails. last is Close's call; the only window of a
+// short dictation is left to finishTranscription's own fallback.
+func (s *chunkedSession) finish(last bool) {
+	c, sess := s.orphan, s.cur
+	s.orphan, s.cur = nil, nil
+	if sess != nil {
+		c = &liveChunk{hard: s.hard}
+		s.chunks = append(s.chunks, c)
+	}
+	if c == nil {
+		return
+	}
+	solo := last && len(s.chunks) == 1
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.sem <- struct{}{}
+		defer func() { <-s.sem }()
+		if sess != nil {
+			c.res, c.err = sess.Close()
+		} else if len(c.pcm) > 0 {
+			c.res.AudioData, c.res.AudioFormat = audio.PCMToWAV(c.pcm), "wav"
+		}
+		if c.err != nil && !solo && s.fallback != nil && len(c.res.AudioData) > 0 {
+			s.fallbackWindow(c)
+		}
+	}()
+}
+
+// fallbackWindow retranscribes one failed window, replacing its result with
+// the fallback's text on success.
+func (s *chunkedSession) fallbackWindow(c *liveChunk) {
+	hit, err := s.fallback(c.res.AudioData, c.res.AudioFormat)
+	if err != nil {
+		c.err = fmt.Errorf("%w (fallback: %v)", c.err, err)
+		return
+	}
+	text := strings.TrimSpace(hit.result.Text)
+	c.res.Text, c.res.HasText, c.res.NoSpeech = text, text != "", text == ""
+	c.err, c.via = nil, hit.tr.Name()
+}
+
+func (s *chunkedSession) Updates() <-chan string { return s.updates }
+
+func (s *chunkedSession) Close() (transcriber.SessionResult, error) {
+	close(s.updates)
+	start := time.Now()
+
+	s.mu.Lock()
+	s.pending = append(s.pending, s.buf...)
+	s.buf = nil
+	s.flush()
+	s.finish(true)
+	chunks := s.chunks
+	s.mu.Unlock()
+	s.wg.Wait()
+
+	if len(chunks) == 1 {
+		return chunks[0].res, chunks[0].err
+	}
+	return joinLiveChunks(chunks, time.Since(start))
+}
+
+// errWindowsFailed marks a chunked dictation whose failed windows already
+// went through fallback one by one: the joined audio must not go again.
+var errWindowsFailed = errors.New("chunked dictation incomplete")
+
+// joinLiveChunks folds the windows' results into one, as if the dictation had
+// been a single session: stitched text, summed sizes and inference, the last
+// window's network timings (the one the user waited on), and wait — how long
+// Close blocked after release — as the total.
+func joinLiveChunks(chunks []*liveChunk, wait time.Duration) (transcriber.SessionResult, error) {
+	var (
+		text  string
+		errs  []error
+		audio []transcriber.SessionResult
+		hard  int
+		via   []string
+	)
+	sum := &transcriber.BatchStats{}
+	var sr transcriber.SessionResult
+	for i, c := range chunks {
+		if c.hard {
+			hard++
+		}
+		if c.err != nil {
+			errs = append(errs, fmt.Errorf("window %d/%d: %w", i+1, len(chunks), c.err))
+		}
+		if c.via != "" {
+			via = append(via, fmt.Sprintf("%d via %s", i+1, c.via))
+		}
+		audio = append(audio, c.res)
+		text = stitchText(text, c.res.Text, c.hard)
+		sr.ProcessRSSMB = max(sr.ProcessRSSMB, c.res.ProcessRSSMB)
+		if c.res.RateLimit != "" {
+			sr.RateLimit = c.res.RateLimit
+		}
+		if b := c.res.Batch; b != nil {
+			sum.AudioLengthS += b.AudioLengthS
+			sum.RawSizeKB += b.RawSizeKB
+			sum.CompressedSizeKB += b.CompressedSizeKB
+			sum.EncodeTimeMs += b.EncodeTimeMs
+			sum.InferenceMs += b.InferenceMs
+			sum.ConvertMs += b.ConvertMs
+			sum.Retries += b.Retries
+			sum.RetryMs += b.RetryMs
+			sum.DNSTimeMs, sum.TLSTimeMs, sum.TTFBMs = b.DNSTimeMs, b.TLSTimeMs, b.TTFBMs
+			sum.ConnReused, sum.TLSProtocol, sum.Confidence = b.ConnReused, b.TLSProtocol, b.Confidence
+		}
+	}
+	sr.AudioData, sr.AudioFormat = joinChunkAudio(audio)
+	if len(errs) > 0 {
+		return sr, fmt.Errorf("%w: %w", errWindowsFailed, errors.Join(errs...))
+	}
+
+	if sum.RawSizeKB > 0 {
+		sum.CompressionPct = (1 - sum.CompressedSizeKB/sum.RawSizeKB) * 100
+	}
+	sum.TotalTimeMs = float64(wait.Microseconds()) / 1000
+	sr.Text = text
+	sr.HasText = text != ""
+	sr.NoSpeech = text == ""
+	sr.Batch = sum
+	sr.Metrics = []string{
+		fmt.Sprintf("audio:      %.1fs in %d windows (%d cut mid-speech)", sum.AudioLengthS, len(chunks), hard),
+		fmt.Sprintf("inference:  %.0fms summed over windows", sum.InferenceMs),
+		fmt.Sprintf("total:      %.0fms waited after release (last window)", sum.TotalTimeMs),
+	}
+	if len(via) > 0 {
+		sr.Metrics = append(sr.Metrics, "fallback:   window "+strings.Join(via, ", window "))
+	}
+	log.Info(fmt.Sprintf("chunked: %.0fs dictation in %d windows", sum.AudioLengthS, len(chunks)))
+	return sr, nil
+}
+
+// joinChunkAudio concatenates the windows' audio for saving and the fallback
+// chain. MP3 frames concatenate as they are; anything else is decoded and
+// rewrapped as one WAV. The overlaps stay in: a few hundred ms repeated at
+// each cut.
+func joinChunkAudio(results []transcriber.SessionResult) ([]byte, string) {
+	mp3 := true
+	for _, r := range results {
+		mp3 = mp3 && r.AudioFormat == "mp3"
+	}
+	var joined []byte
+	for _, r := range results {
+		if len(r.AudioData) == 0 {
+			continue
+		}
+		if mp3 {
+			joined = append(joined, r.AudioData...)
+			continue
+		}
+		pcm, err := audio.DecodeToPCM(r.AudioData, r.AudioFormat)
+		if err != nil {
+			log.Warnf("chunked: window audio not kept: %v", err)
+			continue
+		}
+		joined = append(joined, pcm...)
+	}
+	if mp3 {
+		return joined, "mp3"
+	}
+	if len(joined) == 0 {
+		return nil, ""
+	}
+	return audio.PCMToWAV(joined), "wav"
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"zee/audio"
+	"zee/encoder"
+	"zee/transcriber"
+)
+
+// smallCutter is a chunkCutter scaled down to frame counts a test can write.
+func smallCutter() *chunkCutter {
+	return &chunkCutter{target: 10, max: 20, minPause: 4, overlap: 2}
+}
+
+// loudFrame is the stand-in VAD for these tests: a frame is speech if any byte
+// is non-zero.
+func loudFrame(f []byte) bool { return slices.ContainsFunc(f, func(b byte) bool { return b != 0 }) }
+
+// frames builds PCM from a pattern: 's' is a speech frame numbered by its
+// position (so tests can tell frames apart), '.' a silent one.
+func frames(pattern string) []byte {
+	var pcm []byte
+	for i, c := range pattern {
+		f := make([]byte, vadFrameBytes)
+		if c == 's' {
+			for j := range f {
+				f[j] = byte(i%250 + 1)
+			}
+		}
+		pcm = append(pcm, f...)
+	}
+	return pcm
+}
+
+func TestChunkCutter(t *testing.T) {
+	c := smallCutter()
+	feed := func(pattern string) (cuts []string) {
+		for i, ch := range pattern {
+			if cut, hard, back := c.next(ch == 's'); cut {
+				cuts = append(cuts, fmt.Sprintf("%d hard=%v back=%d", i, hard, back))
+			}
+		}
+		return cuts
+	}
+
+	// A pause before the target is no reason to cut.
+	if got := feed("sss....sss"); got != nil {
+		t.Fatalf("early pause: cuts %v, want none", got)
+	}
+	// Past the target, the first pause of minPause frames cuts in its middle.
+	if got := feed("ss...."); !slices.Equal(got, []string{"5 hard=false back=2"}) {
+		t.Fatalf("pause cut: %v", got)
+	}
+	// The new window already holds back+overlap frames; with no pause it is
+	// cut hard at max.
+	if got := feed(strings.Repeat("s", 20)); !slices.Equal(got, []string{"15 hard=true back=0"}) {
+		t.Fatalf("hard cut: %v", got)
+	}
+}
+
+func TestPlanChunksOverlap(t *testing.T) {
+	pcm := frames(strings.Repeat("s", 12) + "...." + strings.Repeat("s", 30))
+	spans := planChunks(pcm, loudFrame, smallCutter())
+	want := []chunkSpan{
+		{0, 14 * vadFrameBytes, false},                  // cut mid-pause
+		{12 * vadFrameBytes, 32 * vadFrameBytes, false}, // starts overlap frames earlier
+		{30 * vadFrameBytes, len(pcm), true},            // after the hard cut at max
+	}
+	if !slices.Equal(spans, want) {
+		t.Errorf("spans = %v, want %v", spans, want)
+	}
+}
+
+func TestStitchText(t *testing.T) {
+	cases := []struct {
+		a, b string
+		hard bool
+		want string
+	}{
+		{"", "hello there", false, "hello there"},
+		{"hello there", "", true, "hello there"},
+		{"we went to the", "the store", false, "we went to the the store"},
+		{"we went to the", "The store", true, "we went to the store"},
+		{"and then, she said", "She said: no", true, "and then, she said no"},
+		{"completely different", "words here", true, "completely different words here"},
+		{"all of it", "all of it", true, "all of it"},
+	}
+	for _, c := range cases {
+		if got := stitchText(c.a, c.b, c.hard); got != c.want {
+			t.Errorf("stitchText(%q, %q, %v) = %q, want %q", c.a, c.b, c.hard, got, c.want)
+		}
+	}
+}
+
+func TestStitchResultsOffsetsSegments(t *testing.T) {
+	sec := pcmSeconds(vadFrameBytes) // one frame
+	spans := []chunkSpan{{0, 100 * vadFrameBytes, false}, {90 * vadFrameBytes, 200 * vadFrameBytes, true}}
+	results := []*transcriber.Result{
+		{Text: "one two", InferenceMs: 10, Segments: []transcriber.Segment{
+			{Start: 0, End: 50 * sec, Text: "one"}, {Start: 50 * sec, End: 99 * sec, Text: "two"},
+		}},
+		{Text: "two three", InferenceMs: 5, Segments: []transcriber.Segment{
+			{Start: 0, End: 9 * sec, Text: "two"}, {Start: 10 * sec, End: 100 * sec, Text: "three"},
+		}},
+	}
+	got := stitchResults(spans, results, 200*sec)
+	if got.Text != "one two three" || got.InferenceMs != 15 {
+		t.Errorf("text %q, inference %v", got.Text, got.InferenceMs)
+	}
+	var texts []string
+	for _, s := range got.Segments {
+		texts = append(texts, s.Text)
+	}
+	if !slices.Equal(texts, []string{"one", "two", "three"}) {
+		t.Fatalf("segments %v: the seam duplicate should be dropped", texts)
+	}
+	if start := got.Segments[2].Start; start < 99.9*sec || start > 100.1*sec {
+		t.Errorf("second window's segment starts at %.3fs, want %.3fs", start, 100*sec)
+	}
+}
+
+// chunkRecorder is a batch session that keeps what it was fed.
+type chunkRecorder struct {
+	fed     []byte
+	text    string
+	err     error
+	updates chan string
+}
+
+func (r *chunkRecorder) Feed(pcm []byte)        { r.fed = append(r.fed, pcm...) }
+func (r *chunkRecorder) Updates() <-chan string { return r.updates }
+func (r *chunkRecorder) Close() (transcriber.SessionResult, error) {
+	close(r.updates)
+	return transcriber.SessionResult{
+		Text: r.text, HasText: r.text != "",
+		Batch:     &transcriber.BatchStats{AudioLengthS: pcmSeconds(len(r.fed))},
+		AudioData: r.fed, AudioFormat: "mp3",
+	}, r.err
+}
+
+func newChunkRecorders(texts ...string) (*[]*chunkRecorder, func() (transcriber.Session, error)) {
+	var made []*chunkRecorder
+	return &made, func() (transcriber.Session, error) {
+		r := &chunkRecorder{text: texts[len(made)], updates: make(chan string)}
+		made = append(made, r)
+		return r, nil
+	}
+}
+
+func TestChunkedSessionSplitsAtCuts(t *testing.T) {
+	made, next := newChunkRecorders("first part", "then more words", "more words to end")
+	first, _ := next()
+	s := newChunkedSession(first, next, loudFrame, false)
+	s.cutter = smallCutter()
+
+	pcm := frames(strings.Repeat("s", 12) + "...." + strings.Repeat("s", 30))
+	for len(pcm) > 0 { // odd sizes: the session must reassemble frames
+		n := min(len(pcm), 1000)
+		s.Feed(pcm[:n])
+		pcm = pcm[n:]
+	}
+	res, err := s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pcm = frames(strings.Repeat("s", 12) + "...." + strings.Repeat("s", 30))
+	spans := planChunks(pcm, loudFrame, smallCutter())
+	if len(*made) != len(spans) {
+		t.Fatalf("%d sessions, want %d", len(*made), len(spans))
+	}
+	for i, sp := range spans {
+		if !bytes.Equal((*made)[i].fed, pcm[sp.start:sp.end]) {
+			t.Errorf("window %d: fed %d bytes, want frames %d..%d", i, len((*made)[i].fed),
+				sp.start/vadFrameBytes, sp.end/vadFrameBytes)
+		}
+	}
+	if res.Text != "first part then more words to end" {
+		t.Errorf("text = %q", res.Text)
+	}
+	if res.AudioFormat != "mp3" || res.Batch == nil {
+		t.Errorf("format %q, batch %v", res.AudioFormat, res.Batch)
+	}
+}
+
+// TestChunkedSessionFallsBackPerWindow: a window whose request fails, or
+// whose session never opens, goes through fallback alone, with its own audio.
+func TestChunkedSessionFallsBackPerWindow(t *testing.T) {
+	made, next := newChunkRecorders("first part", "lost", "")
+	first, _ := next()
+	opened := 1
+	s := newChunkedSession(first, func() (transcriber.Session, error) {
+		opened++
+		if opened == 3 {
+			return nil, errors.New("dial failed")
+		}
+		sess, _ := next()
+		sess.(*chunkRecorder).err = errors.New("503")
+		return sess, nil
+	}, loudFrame, false)
+	s.cutter = smallCutter()
+	var sent []int
+	s.fallback = func(data []byte, format string) (*fallbackHit, error) {
+		if format == "wav" { // a recorder's "mp3" is its raw PCM
+			data, _ = audio.WAVToPCM(data)
+		}
+		sent = append(sent, len(data)/vadFrameBytes)
+		return &fallbackHit{
+			result: &transcriber.Result{Text: fmt.Sprintf("rescued%d", len(sent))},
+			tr:     transcriber.NewFake("", nil),
+		}, nil
+	}
+
+	pcm := frames(strings.Repeat("s", 12) + "...." + strings.Repeat("s", 30))
+	s.Feed(pcm)
+	res, err := s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "first part rescued1 rescued2" && res.Text != "first part rescued2 rescued1" {
+		t.Errorf("text = %q", res.Text)
+	}
+	spans := planChunks(pcm, loudFrame, smallCutter())
+	if len(*made) != 2 || len(sent) != 2 {
+		t.Fatalf("%d sessions, %d fallbacks; want 2 and 2", len(*made), len(sent))
+	}
+	slices.Sort(sent)
+	want := []int{(spans[2].end - spans[2].start) / vadFrameBytes, (spans[1].end - spans[1].start) / vadFrameBytes}
+	slices.Sort(want)
+	if !slices.Equal(sent, want) {
+		t.Errorf("fallback heard %v frames, want each window's own %v", sent, want)
+	}
+	if !slices.ContainsFunc(res.Metrics, func(m string) bool { return strings.HasPrefix(m, "fallback:") }) {
+		t.Errorf("metrics %q don't name the fallback", res.Metrics)
+	}
+}
+
+func TestChunkedSessionShortIsPassthrough(t *testing.T) {
+	made, next := newChunkRecorders("just this")
+	first, _ := next()
+	s := newChunkedSession(first, next, loudFrame, false)
+	pcm := frames("sss..sss") // well under chunkTarget
+	s.Feed(pcm)
+	s.Feed([]byte{1, 2, 3}) // a partial frame is still delivered at Close
+	res, err := s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*made) != 1 || !bytes.Equal((*made)[0].fed, append(pcm, 1, 2, 3)) {
+		t.Errorf("single session fed %d bytes, want %d", len((*made)[0].fed), len(pcm)+3)
+	}
+	if res.Text != "just this" || !bytes.Equal(res.AudioData, (*made)[0].fed) {
+		t.Errorf("result not passed through: %q", res.Text)
+	}
+}
+
+// windowTranscriber answers each window with its number and length in whole
+// seconds, as one segment spanning it.
+type windowTranscriber struct{ formats []string }
+
+func (w *windowTranscriber) Transcribe(data []byte, format, _, _ string) (*transcriber.Result, error) {
+	w.formats = append(w.formats, format)
+	pcm, err := audio.DecodeToPCM(data, format)
+	if err != nil {
+		return nil, err
+	}
+	d := pcmSeconds(len(pcm))
+	text := fmt.Sprintf("w%d=%.0fs", len(w.formats), d)
+	return &transcriber.Result{Text: text, Segments: []transcriber.Segment{{Start: 0, End: d, Text: text}}}, nil
+}
+
+func TestTranscribeChunkedCutsAtMax(t *testing.T) {
+	// Nine silent minutes: the one pause never ends, so there is no pause cut
+	// and the windows are cut hard at chunkMax.
+	pcm := make([]byte, 9*60*encoder.SampleRate*2)
+	var w windowTranscriber
+	res, err := transcribeChunked(&w, pcm, true, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "w1=240s w2=240s w3=61s" {
+		t.Errorf("text = %q", res.Text)
+	}
+	if len(res.Segments) != 3 || res.Segments[1].Start < 239 || res.Segments[2].Start < 479 {
+		t.Errorf("segments = %+v", res.Segments)
+	}
+	if !slices.Equal(w.formats, []string{"wav", "wav", "wav"}) {
+		t.Errorf("local windows sent as %v, want WAV", w.formats)
+	}
+}
@@ -8,6 +8,8 @@ package clipboard
 int clipCopy(const char *utf8);
 char *clipRead(void);
 void clipPaste(void);
+void clipEnter(void);
+void clipCopySelection(void);
 
 static int testAccessibility() {
 	return AXIsProcessTrusted();
@@ -50,6 +52,19 @@ func Paste() error {
 	return nil
 }
 
+// Enter presses Return, like Paste presses Cmd+V.
+func Enter() error {
+	C.clipEnter()
+	return nil
+}
+
+// CopySelection fires Cmd+C: the focused app copies its selection to the
+// pasteboard, asynchronously — poll Read for it.
+func CopySelection() error {
+	C.clipCopySelection()
+	return nil
+}
+
 func CheckAccessibility() bool {
 	return C.testAccessibility() == 1
 }
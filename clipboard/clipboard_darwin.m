@@ -53,3 +53,31 @@ void clipPaste(void) {
 	CFRelease(down);
 	CFRelease(up);
 }
+
+// clipCopySelection synthesizes Cmd+C, copying the focused app's selection for
+// command mode. Same mechanism as clipPaste.
+void clipCopySelection(void) {
+	const CGKeyCode kVK_C = 0x08;
+	CGEventRef down = CGEventCreateKeyboardEvent(NULL, kVK_C, true);
+	CGEventRef up = CGEventCreateKeyboardEvent(NULL, kVK_C, false);
+	CGEventSetFlags(down, kCGEventFlagMaskCommand);
+	CGEventSetFlags(up, kCGEventFlagMaskCommand);
+	CGEventPost(kCGAnnotatedSessionEventTap, down);
+	CGEventPost(kCGAnnotatedSessionEventTap, up);
+	CFRelease(down);
+	CFRelease(up);
+}
+
+// clipEnter synthesizes Return, for a dictated "press enter" after the paste.
+// Same tap and explicit flags as clipPaste.
+void clipEnter(void) {
+	const CGKeyCode kVK_Return = 0x24;
+	CGEventRef down = CGEventCreateKeyboardEvent(NULL, kVK_Return, true);
+	CGEventRef up = CGEventCreateKeyboardEvent(NULL, kVK_Return, false);
+	CGEventSetFlags(down, 0);
+	CGEventSetFlags(up, 0);
+	CGEventPost(kCGAnnotatedSessionEventTap, down);
+	CGEventPost(kCGAnnotatedSessionEventTap, up);
+	CFRelease(down);
+	CFRelease(up);
+}
@@ -131,12 +131,27 @@ func syn() error {
 	return writeEvent(evSyn, 0, 0)
 }
 
-func Paste() error {
+// Key codes from linux/input-event-codes.h.
+const (
+	keyEnter    = 28
+	keyLeftCtrl = 29
+	keyC        = 46
+	keyV        = 47
+)
+
+func Paste() error { return ctrlChord(keyV) }
+
+// CopySelection presses Ctrl+C: the focused app copies its selection to the
+// clipboard, asynchronously — poll Read for it.
+func CopySelection() error { return ctrlChord(keyC) }
+
+// ctrlChord presses Ctrl+key.
+func ctrlChord(key uint16) error {
 	if err := Init(); err != nil {
 		return err
 	}
 	// Ctrl down
-	if err := writeEvent(evKey, 29, 1); err != nil {
+	if err := writeEvent(evKey, keyLeftCtrl, 1); err != nil {
 		return err
 	}
 	if err := syn(); err != nil {
@@ -144,16 +159,16 @@ func Paste() error {
 	}
 	// Let compositor register modifier state
 	time.Sleep(5 * time.Millisecond)
-	// V down
-	if err := writeEvent(evKey, 47, 1); err != nil {
+	// key down
+	if err := writeEvent(evKey, key, 1); err != nil {
 		return err
 	}
 	if err := syn(); err != nil {
 		return err
 	}
 	time.Sleep(5 * time.Millisecond)
-	// V up
-	if err := writeEvent(evKey, 47, 0); err != nil {
+	// key up
+	if err := writeEvent(evKey, key, 0); err != nil {
 		return err
 	}
 	if err := syn(); err != nil {
@@ -161,7 +176,25 @@ func Paste() error {
 	}
 	time.Sleep(5 * time.Millisecond)
 	// Ctrl up
-	if err := writeEvent(evKey, 29, 0); err != nil {
+	if err := writeEvent(evKey, keyLeftCtrl, 0); err != nil {
+		return err
+	}
+	return syn()
+}
+
+// Enter presses Return, like Paste presses Ctrl+V.
+func Enter() error {
+	if err := Init(); err != nil {
+		return err
+	}
+	if err := writeEvent(evKey, keyEnter, 1); err != nil {
+		return err
+	}
+	if err := syn(); err != nil {
+		return err
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := writeEvent(evKey, keyEnter, 0); err != nil {
 		return err
 	}
 	return syn()
@@ -38,6 +38,39 @@ func (c *clipboardSession) PasteText(text string) (copyMs, keyMs float64) {
 	return copyMs, keyMs
 }
 
+// PressEnter sends Return after a paste (a dictated "press enter"), serialized
+// with PasteText so it lands after the text.
+func (c *clipboardSession) PressEnter() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := clipboard.Enter(); err != nil {
+		log.Warnf("paste: enter keystroke failed: %v", err)
+	}
+}
+
+// CopySelection copies the focused app's selection and returns it, "" when
+// nothing is selected. The clipboard is cleared first so an unchanged one
+// can't pass for a selection; the caller restores it.
+func (c *clipboardSession) CopySelection() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := clipboard.Copy(""); err != nil {
+		log.Warnf("selection: clipboard clear failed: %v", err)
+		return ""
+	}
+	if err := clipboard.CopySelection(); err != nil {
+		log.Warnf("selection: copy keystroke failed (Accessibility?): %v", err)
+		return ""
+	}
+	// The app answers the keystroke in its own time.
+	for deadline := time.Now().Add(500 * time.Millisecond); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if text, _ := clipboard.Read(); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
 func (c *clipboardSession) SaveCurrent() string {
 	prev, _ := clipboard.Read()
 	return prev
@@ -86,3 +119,9 @@ func (c *clipboardSession) SetLastText(text string) {
 	c.lastText = text
 	c.textMu.Unlock()
 }
+
+func (c *clipboardSession) LastText() string {
+	c.textMu.Lock()
+	defer c.textMu.Unlock()
+	return c.lastText
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"zee/config"
+	"zee/hotkey"
+	"zee/log"
+	"zee/postprocess"
+	"zee/tray"
+)
+
+// Command mode: a second hotkey records a spoken instruction ("make this more
+// formal", "translate to German") instead of text. Once the keys are up the
+// focused app's selection is copied, and the instruction and selection go to
+// the configured chat endpoint; its reply is pasted back over the selection,
+// and the clipboard restored as after any paste. Everything else — capture,
+// transcription, fallback, the busy guard — is the dictation cycle's.
+
+// commandMode is a command-mode cycle's state.
+type commandMode struct {
+	rewriter  *postprocess.Rewriter
+	selection chan string // filled once recording has ended
+}
+
+var (
+	errNoSelection          = errors.New("nothing is selected")
+	errCommandAccessibility = errors.New("command mode needs Accessibility permission to copy and replace the selection")
+)
+
+// currentRewriter is command mode's model, or nil unless config.json names an
+// endpoint.
+func currentRewriter() *postprocess.Rewriter {
+	cc := config.Get().Command
+	if strings.TrimSpace(cc.BaseURL) == "" {
+		return nil
+	}
+	return &postprocess.Rewriter{ChatEndpoint: postprocess.ChatEndpoint{
+		BaseURL: cc.BaseURL,
+		Model:   cc.Model,
+		APIKey:  config.APIKey("command"),
+		Budget:  time.Duration(cc.BudgetMs) * time.Millisecond,
+	}}
+}
+
+// registerCommandHotkey binds config.json's command-mode hotkey, if any, next
+// to the dictation hotkey. A failure costs command mode, not the app.
+func registerCommandHotkey(dictation hotkey.Combo, sessions chan<- recSession) hotkey.Hotkey {
+	cc := config.Get().Command
+	if cc.Hotkey.IsZero() {
+		return nil
+	}
+	if cc.Hotkey.Equal(dictation) {
+		log.Warnf("command hotkey %s is the dictation hotkey; command mode is off", cc.Hotkey.Label)
+		tray.SetError("The command-mode hotkey is the dictation hotkey — pick another in config.json.")
+		return nil
+	}
+	if strings.TrimSpace(cc.BaseURL) == "" {
+		log.Warn("command hotkey set without command.base_url; command mode is off")
+		return nil
+	}
+	hk := hotkey.New(cc.Hotkey)
+	if err := hk.Register(); err != nil {
+		log.Warnf("command hotkey %s: %v", cc.Hotkey.Label, err)
+		tray.SetError("The command-mode hotkey couldn't be registered: " + err.Error())
+		return nil
+	}
+	go listenCommandHotkey(hk, hotkey.LongPress(), sessions)
+	return hk
+}
+
+// listenCommandHotkey is listenHotkey for command mode: the same press
+// semantics, a command-mode session.
+func listenCommandHotkey(hk hotkey.Hotkey, longPress time.Duration, sessions chan<- recSession) {
+	listenPresses(hk, longPress, sessions, true)
+}
+
+// rewriteSelection applies the transcribed instruction to the selection
+// captured for cm and reports how long the model took.
+func rewriteSelection(cm *commandMode, instruction string) (string, float64, error) {
+	selection := <-cm.selection
+	if cm.rewriter == nil {
+		return "", 0, errors.New("no command.base_url in config.json")
+	}
+	if strings.TrimSpace(selection) == "" {
+		return "", 0, errNoSelection
+	}
+	t := time.Now()
+	out, err := cm.rewriter.Rewrite(context.Background(), selection, instruction)
+	ms := float64(time.Since(t).Microseconds()) / 1000
+	if err != nil {
+		return "", ms, err
+	}
+	log.Info(fmt.Sprintf("command_mode selection_chars=%d rewrite_ms=%.0f model=%s", len([]rune(selection)), ms, cm.rewriter.Model))
+	return out, ms, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cachedFile is a text file in Dir() the user edits while zee runs (hints,
+// replacements, the polish prompt): it is re-read only when its mtime
+// changes, so an edit applies to the next dictation without a stat-and-read
+// on every one, and is created from seed on first use.
+type cachedFile struct {
+	name  string
+	seed  string
+	parse func(data string) string // nil keeps the file as written
+
+	mu      sync.Mutex
+	cache   string
+	modTime time.Time
+}
+
+func (c *cachedFile) path() string {
+	return filepath.Join(Dir(), c.name)
+}
+
+// get returns the parsed file, or the last good read when it can't be read.
+func (c *cachedFile) get() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, err := os.Stat(c.path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			os.MkdirAll(Dir(), 0755)
+			if os.WriteFile(c.path(), []byte(c.seed), 0644) == nil {
+				// modTime stays unset, so the next call reads the file back.
+				c.cache = c.apply(c.seed)
+			}
+		}
+		return c.cache
+	}
+	if info.ModTime().Equal(c.modTime) {
+		return c.cache
+	}
+	data, err := os.ReadFile(c.path())
+	if err != nil {
+		return c.cache
+	}
+	c.cache = c.apply(string(data))
+	c.modTime = info.ModTime()
+	return c.cache
+}
+
+func (c *cachedFile) apply(data string) string {
+	if c.parse == nil {
+		return data
+	}
+	return c.parse(data)
+}
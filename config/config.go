@@ -3,9 +3,11 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -27,6 +29,144 @@ type Settings struct {
 	// TailWaitMs keeps the mic open this many ms after the hotkey is released so
 	// a fast keyup doesn't clip the last word. 0 disables the wait.
 	TailWaitMs int `json:"tail_wait_ms"`
+	// Compatible points the "compatible" provider at any server speaking
+	// OpenAI's /v1/audio/transcriptions protocol. Its optional API key lives in
+	// credentials.json under "compatible", like every other provider's.
+	Compatible Compatible `json:"compatible"`
+	// Fallback is the ordered provider chain a failed transcription is
+	// re-submitted to: "groq" (provider default model) or "groq:model-id".
+	// Empty keeps the old behavior — save the audio and alert.
+	Fallback []string `json:"fallback,omitempty"`
+	// Vosk is the vosk-server WebSocket for the "vosk" provider (offline
+	// streaming from a local sidecar), e.g. {"url": "ws://localhost:2700"}.
+	Vosk Vosk `json:"vosk"`
+	// Wyoming is a Wyoming-protocol ASR server (wyoming-faster-whisper, …) for
+	// the "wyoming" provider, e.g. {"host": "localhost", "port": 10300}.
+	Wyoming Wyoming `json:"wyoming"`
+	// SpokenCommands turns dictated "new line", "comma", "scratch that", …
+	// into what they name. Off by default: with it on, those words can't be
+	// dictated as words.
+	SpokenCommands SpokenCommands `json:"spoken_commands"`
+	// Polish sends each dictation through an LLM for cleanup before it is
+	// pasted. Off unless Enabled: it ships the text to another server and adds
+	// its latency to every paste.
+	Polish Polish `json:"polish"`
+	// Command is command mode: its hotkey records a spoken instruction that
+	// an LLM applies to the selected text, pasted back over the selection.
+	// Off while its Hotkey is unset.
+	Command Command `json:"command"`
+	// History keeps every dictation in history.jsonl for `zee history` and
+	// the tray's Recent menu. Off unless Enabled: it is the text of
+	// everything dictated, in plain text.
+	History History `json:"history"`
+	// Archive keeps every dictation's audio and metadata — a corpus of your
+	// own voice for evaluating providers. Off unless Enabled.
+	Archive Archive `json:"archive"`
+}
+
+// Archive is where recordings are kept and for how long: Dir ("" is
+// archive/ in the config dir), MaxDays and MaxGB (0 doesn't limit; the
+// oldest go first). With Encrypt, files are sealed with a key derived from
+// the passphrase in $ZEE_ARCHIVE_PASSPHRASE (or, failing that,
+// credentials.json's "archive"), and nothing is archived without one.
+type Archive struct {
+	Enabled bool    `json:"enabled"`
+	Dir     string  `json:"dir,omitempty"`
+	MaxDays int     `json:"max_days,omitempty"`
+	MaxGB   float64 `json:"max_gb,omitempty"`
+	Encrypt bool    `json:"encrypt,omitempty"`
+}
+
+// History is the dictation history's retention: entries beyond MaxEntries or
+// older than MaxDays are dropped as new ones arrive. 0 doesn't limit.
+type History struct {
+	Enabled    bool `json:"enabled"`
+	MaxEntries int  `json:"max_entries"`
+	MaxDays    int  `json:"max_days"`
+}
+
+// Command is command mode's hotkey and chat endpoint; the optional API key is
+// credentials.json's "command". BudgetMs caps the wait for the rewrite (0:
+// 15000); past it the selection is left as it was.
+type Command struct {
+	Hotkey   hotkey.Combo `json:"hotkey"`
+	BaseURL  string       `json:"base_url"`
+	Model    string       `json:"model"`
+	BudgetMs int          `json:"budget_ms,omitempty"`
+}
+
+// Polish is the OpenAI-compatible chat-completions endpoint of the polish
+// pass; the prompt is polish.txt, the optional API key credentials.json's
+// "polish". BudgetMs caps the wait for the reply (0: 1500); past it the raw
+// text is pasted.
+type Polish struct {
+	Enabled  bool   `json:"enabled"`
+	BaseURL  string `json:"base_url"` // e.g. "https://api.groq.com/openai/v1"
+	Model    string `json:"model"`
+	Tone     string `json:"tone,omitempty"`
+	BudgetMs int    `json:"budget_ms,omitempty"`
+}
+
+// SpokenCommands enables the command phrases and adjusts them per language:
+// Phrases["de"]["absatz"] = "paragraph" adds one, "" removes a built-in.
+type SpokenCommands struct {
+	Enabled bool                         `json:"enabled"`
+	Phrases map[string]map[string]string `json:"phrases,omitempty"`
+}
+
+// Vosk is the vosk-server endpoint. An empty URL leaves the provider
+// unavailable.
+type Vosk struct {
+	URL string `json:"url"`
+}
+
+// Wyoming is a Wyoming server's TCP address. An empty Host leaves the
+// provider unavailable; Port 0 means the protocol's usual 10300.
+type Wyoming struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+const defaultWyomingPort = 10300
+
+// ServerModel returns the model a self-hosted provider is configured to ask
+// its server for, or "" when the server decides (all but compatible).
+func ServerModel(provider string) string {
+	if provider == "compatible" {
+		return strings.TrimSpace(Get().Compatible.Model)
+	}
+	return ""
+}
+
+// ServerAddr returns the configured address of a self-hosted provider (e.g.
+// "vosk"), or "" if none — the server-side counterpart of APIKey.
+func ServerAddr(provider string) string {
+	s := Get()
+	switch provider {
+	case "compatible":
+		return strings.TrimSpace(s.Compatible.BaseURL)
+	case "vosk":
+		return strings.TrimSpace(s.Vosk.URL)
+	case "wyoming":
+		host := strings.TrimSpace(s.Wyoming.Host)
+		if host == "" {
+			return ""
+		}
+		port := s.Wyoming.Port
+		if port == 0 {
+			port = defaultWyomingPort
+		}
+		return net.JoinHostPort(host, strconv.Itoa(port))
+	}
+	return ""
+}
+
+// Compatible is the user-supplied endpoint of the OpenAI-compatible provider.
+// An empty BaseURL leaves the provider unavailable; an empty Model lets the
+// provider pick its default.
+type Compatible struct {
+	BaseURL string `json:"base_url"` // e.g. "http://localhost:8000/v1"
+	Model   string `json:"model"`
 }
 
 const settingsFile = "config.json"
@@ -47,6 +187,7 @@ var (
 		Language:   "en",
 		AutoPaste:  true,
 		TailWaitMs: defaultTailWaitMs,
+		History:    History{MaxEntries: 1000, MaxDays: 90},
 	}
 )
 
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestSettingsDefaults(t *testing.T) {
@@ -182,3 +183,54 @@ func TestReloadExternalEdits(t *testing.T) {
 		t.Fatalf("Get().Language = %q after failed reload, want fr (untouched)", got)
 	}
 }
+
+func TestServerAddr(t *testing.T) {
+	SetDir(t.TempDir())
+	if err := Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := ServerAddr("wyoming"); got != "" {
+		t.Errorf("unconfigured wyoming = %q, want empty", got)
+	}
+
+	Update(func(s *Settings) {
+		s.Vosk.URL = " ws://localhost:2700 "
+		s.Wyoming.Host = "asr.lan"
+	})
+	if got := ServerAddr("vosk"); got != "ws://localhost:2700" {
+		t.Errorf("vosk = %q", got)
+	}
+	if got := ServerAddr("wyoming"); got != "asr.lan:10300" {
+		t.Errorf("wyoming = %q, want the default port", got)
+	}
+	Update(func(s *Settings) { s.Wyoming.Port = 10555 })
+	if got := ServerAddr("wyoming"); got != "asr.lan:10555" {
+		t.Errorf("wyoming = %q, want the configured port", got)
+	}
+	Update(func(s *Settings) { s.Compatible = Compatible{BaseURL: " http://localhost:8000/v1 ", Model: "small"} })
+	if got, model := ServerAddr("compatible"), ServerModel("compatible"); got != "http://localhost:8000/v1" || model != "small" {
+		t.Errorf("compatible = %q, %q", got, model)
+	}
+	if got := ServerAddr("groq"); got != "" {
+		t.Errorf("groq = %q, want empty (not a server provider)", got)
+	}
+}
+
+func TestReplacementsReload(t *testing.T) {
+	SetDir(t.TempDir())
+	GetReplacements() // writes the commented template
+	if data, err := os.ReadFile(ReplacementsPath()); err != nil || len(data) == 0 {
+		t.Fatalf("template not written: %v", err)
+	}
+	os.WriteFile(ReplacementsPath(), []byte("get hub => GitHub\n"), 0644)
+	if got := GetReplacements(); got != "get hub => GitHub\n" {
+		t.Fatalf("GetReplacements = %q", got)
+	}
+	// A later edit is picked up by its mtime.
+	os.WriteFile(ReplacementsPath(), []byte("teh => the\n"), 0644)
+	later := time.Now().Add(2 * time.Second)
+	os.Chtimes(ReplacementsPath(), later, later)
+	if got := GetReplacements(); got != "teh => the\n" {
+		t.Fatalf("after edit = %q", got)
+	}
+}
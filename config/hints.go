@@ -2,10 +2,7 @@ package config
 
 import (
 	"bufio"
-	"os"
-	"path/filepath"
 	"strings"
-	"time"
 )
 
 const hintsFile = "hints.txt"
@@ -13,6 +10,7 @@ const hintsFile = "hints.txt"
 const hintsHeader = `# Vocabulary hints for transcription (one per line)
 # These help the model recognize domain-specific terms
 # Empty lines and lines starting with # are ignored
+# Deepgram reads an optional weight: Grafana:2 (heavier terms go first)
 Opus
 Claude
 Sonnet
@@ -33,45 +31,33 @@ Bun
 Node.js
 `
 
+var hintsCached = cachedFile{name: hintsFile, seed: hintsHeader, parse: parseHints}
+
 func HintsPath() string {
-	return filepath.Join(Dir(), hintsFile)
+	return hintsCached.path()
 }
 
 var (
-	hintsCache   string
-	hintsModTime time.Time
-	hintsFixed   bool
+	hintsOverride string
+	hintsFixed    bool
 )
 
 func SetHints(s string) {
-	hintsCache = s
+	hintsOverride = s
 	hintsFixed = true
 }
 
 func GetHints() string {
 	if hintsFixed {
-		return hintsCache
-	}
-	info, err := os.Stat(HintsPath())
-	if err != nil {
-		if os.IsNotExist(err) {
-			os.MkdirAll(Dir(), 0755)
-			os.WriteFile(HintsPath(), []byte(hintsHeader), 0644)
-		}
-		return hintsCache
-	}
-	if info.ModTime().Equal(hintsModTime) {
-		return hintsCache
+		return hintsOverride
 	}
+	return hintsCached.get()
+}
 
-	f, err := os.Open(HintsPath())
-	if err != nil {
-		return hintsCache
-	}
-	defer f.Close()
-
+// parseHints joins hints.txt's terms, skipping blank lines and comments.
+func parseHints(data string) string {
 	var hints []string
-	sc := bufio.NewScanner(f)
+	sc := bufio.NewScanner(strings.NewReader(data))
 	for sc.Scan() {
 		line := strings.TrimSpace(sc.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
@@ -79,7 +65,5 @@ func GetHints() string {
 		}
 		hints = append(hints, line)
 	}
-	hintsCache = strings.Join(hints, ", ")
-	hintsModTime = info.ModTime()
-	return hintsCache
+	return strings.Join(hints, ", ")
 }
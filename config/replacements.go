@@ -0,0 +1,26 @@
+package config
+
+const replacementsFile = "replacements.txt"
+
+const replacementsHeader = `# Replacements applied to each dictation before it is pasted (one rule per line)
+# Empty lines and lines starting with # are ignored; rules run top to bottom
+#
+#   get hub => GitHub          literal: matched in any case, inserted as written
+#   ~teh => the                case-preserving: Teh -> The, TEH -> THE
+#   <js> => JavaScript         whole words only (combines: ~<teh> => the)
+#   /colou?r/ => color         regular expression (RE2, /…/i ignores case); $1 is a group
+#   <um> =>                    an empty replacement deletes ("umbrella" keeps its um)
+`
+
+// replacementsCached is replacements.txt as written, re-read only when its
+// mtime changes — an edit applies to the next dictation, like hints.txt.
+var replacementsCached = cachedFile{name: replacementsFile, seed: replacementsHeader}
+
+func ReplacementsPath() string {
+	return replacementsCached.path()
+}
+
+// GetReplacements returns replacements.txt as written.
+func GetReplacements() string {
+	return replacementsCached.get()
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"zee/config"
+	"zee/log"
+)
+
+// The control socket lets window-manager keybindings, status bars and editors
+// drive zee — on Linux the tray is a no-op, so without it the evdev hotkey is
+// the only way in. It is a unix socket in the config dir speaking one command
+// per line, each answered with one JSON line:
+//
+//	start | stop | toggle | cancel       → {"ok":true} or {"ok":false,"error":…}
+//	status                               → {"ok":true,"state":"idle",…}
+//	last                                 → {"ok":true,"text":…}
+//	switch-model <provider>[:<model>]    → {"ok":true,"provider":…,"model":…}
+//	subscribe                            → {"ok":true}, then one event per line
+//
+// Commands funnel into the hotkey's paths (claimSession under
+// tryStartSession, requestStop), so the busy guard and cycle bookkeeping are
+// the same; a denied command answers with an error instead of the hotkey's
+// beep-and-dialog, since a script, not a person at the keyboard, is asking.
+
+// controlSocketName is the socket's file name in config.Dir().
+const controlSocketName = "zee.sock"
+
+func controlSocketPath() string { return filepath.Join(config.Dir(), controlSocketName) }
+
+// controlServer answers control connections. switchModel is run()'s guarded
+// model switch, nil where there is none (tests).
+type controlServer struct {
+	ln          net.Listener
+	sessions    chan<- recSession
+	switchModel func(provider, model string) (string, string, error)
+}
+
+// controlSrv is the running app's control socket, closed on shutdown so the
+// socket file goes with the process. nil when it couldn't be opened.
+var controlSrv *controlServer
+
+// stopCancelled marks the active cycle cancelled: its recording is dropped
+// rather than transcribed, or, if already transcribing, its text is not
+// pasted. resetStop clears it for the next cycle.
+var stopCancelled atomic.Bool
+
+// requestCancel ends the active recording like requestStop, discarding it.
+func requestCancel() {
+	stopCancelled.Store(true)
+	requestStop()
+}
+
+// listenControl opens the control socket, replacing a stale one left by a
+// crash. A socket that still answers belongs to a running zee and is left
+// alone.
+func listenControl(path string, sessions chan<- recSession, switchModel func(string, string) (string, string, error)) (*controlServer, error) {
+	if c, err := net.DialTimeout("unix", path, 200*time.Millisecond); err == nil {
+		c.Close()
+		return nil, fmt.Errorf("%s is in use by another zee", path)
+	}
+	os.Remove(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	os.Chmod(path, 0600) // commands can dictate and read transcripts: owner only
+	s := &controlServer{ln: ln, sessions: sessions, switchModel: switchModel}
+	go s.serve()
+	return s, nil
+}
+
+func (s *controlServer) Close() { s.ln.Close() } // removes the socket file too
+
+func (s *controlServer) serve() {
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Warnf("control: %v", err)
+			}
+			return
+		}
+		go s.handle(c)
+	}
+}
+
+func (s *controlServer) handle(c net.Conn) {
+	defer c.Close()
+	enc := json.NewEncoder(c)
+	sc := bufio.NewScanner(c)
+	for sc.Scan() {
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(sc.Text()), " ")
+		if cmd == "" {
+			continue
+		}
+		if cmd == "subscribe" {
+			s.subscribe(c, enc)
+			return
+		}
+		resp := s.command(cmd, strings.TrimSpace(arg))
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// command runs one request and returns its response object.
+func (s *controlServer) command(cmd, arg string) map[string]any {
+	fail := func(format string, args ...any) map[string]any {
+		return map[string]any{"ok": false, "error": fmt.Sprintf(format, args...)}
+	}
+	ok := map[string]any{"ok": true}
+	switch cmd {
+	case "start":
+		if err := s.start(); err != nil {
+			return fail("%v", err)
+		}
+	case "stop":
+		if !isRecording.Load() || isTranscribing.Load() {
+			return fail("not recording")
+		}
+		requestStop()
+	case "toggle":
+		switch {
+		case isTranscribing.Load():
+			return fail("%v", errControlBusy)
+		case isRecording.Load():
+			requestStop()
+		default:
+			if err := s.start(); err != nil {
+				return fail("%v", err)
+			}
+		}
+	case "cancel":
+		if !isRecording.Load() {
+			return fail("nothing to cancel")
+		}
+		requestCancel()
+	case "status":
+		return controlStatus()
+	case "last":
+		ok["text"] = clip.LastText()
+	case "switch-model":
+		if s.switchModel == nil {
+			return fail("switch-model is not available")
+		}
+		provider, model, _ := strings.Cut(arg, ":")
+		if provider == "" {
+			return fail("usage: switch-model <provider>[:<model>]")
+		}
+		provider, model, err := s.switchModel(provider, model)
+		if err != nil {
+			return fail("%v", err)
+		}
+		ok["provider"], ok["model"] = provider, model
+	default:
+		return fail("unknown command %q", cmd)
+	}
+	return ok
+}
+
+// controlRequest sends one command line to the socket at path and returns
+// the decoded answer. For clients: a second launch forwarding to the app.
+func controlRequest(path, line string) (map[string]any, error) {
+	c, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := fmt.Fprintln(c, line); err != nil {
+		return nil, err
+	}
+	var resp map[string]any
+	if err := json.NewDecoder(c).Decode(&resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// errControlBusy is the control socket's answer where the hotkey would beep.
+var errControlBusy = errors.New("busy: recording or transcribing")
+
+// start is tryStartSession without the dialog.
+func (s *controlServer) start() error {
+	if claimSession(s.sessions) == nil {
+		return errControlBusy
+	}
+	return nil
+}
+
+func controlState() string {
+	switch {
+	case isTranscribing.Load():
+		return "transcribing"
+	case isRecording.Load():
+		return "recording"
+	}
+	return "idle"
+}
+
+func controlStatus() map[string]any {
+	configMu.Lock()
+	defer configMu.Unlock()
+	st := map[string]any{
+		"ok":         true,
+		"state":      controlState(),
+		"stream":     streamEnabled,
+		"auto_paste": autoPaste,
+	}
+	if activeTranscriber != nil {
+		st["provider"] = activeTranscriber.Name()
+		st["model"] = activeTranscriber.GetModel()
+		st["language"] = activeTranscriber.GetLanguage()
+	}
+	return st
+}
+
+// subscribe streams events to c until it closes or falls too far behind.
+func (s *controlServer) subscribe(c net.Conn, enc *json.Encoder) {
+	ch := events.add()
+	defer events.remove(ch)
+	if enc.Encode(map[string]any{"ok": true, "state": controlState()}) != nil {
+		return
+	}
+	// The client sends nothing more; its closing is the only read to expect.
+	closed := make(chan struct{})
+	go func() {
+		var b [1]byte
+		for {
+			if _, err := c.Read(b[:]); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok || enc.Encode(ev) != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// eventBus fans events out to subscribers. A subscriber that stops reading is
+// dropped (its channel closed) rather than allowed to stall the record loop.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[chan map[string]any]bool
+}
+
+var events eventBus
+
+func (b *eventBus) add() chan map[string]any {
+	ch := make(chan map[string]any, 64)
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = map[chan map[string]any]bool{}
+	}
+	b.subs[ch] = true
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *eventBus) remove(ch chan map[string]any) {
+	b.mu.Lock()
+	if b.subs[ch] {
+		delete(b.subs, ch)
+		close(ch)
+	}
+	b.mu.Unlock()
+}
+
+// emitEvent publishes one event: recording_started, transcribing, text,
+// error, cancelled or idle. fields are added to the event object.
+func emitEvent(name string, fields map[string]any) {
+	ev := map[string]any{"event": name, "time": time.Now().Format(time.RFC3339Nano)}
+	for k, v := range fields {
+		ev[k] = v
+	}
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	for ch := range events.subs {
+		select {
+		case ch <- ev:
+		default:
+			delete(events.subs, ch)
+			close(ch)
+		}
+	}
+}
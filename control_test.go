@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"zee/audio"
+	"zee/encoder"
+	"zee/transcriber"
+)
+
+// startControl runs the record loop on a fake mic behind a control socket and
+// returns the socket path; everything is torn down at test end.
+func startControl(t *testing.T) string {
+	t.Helper()
+	audio.DisableBeep()
+	isRecording.Store(false)
+	orig := activeTranscriber
+	t.Cleanup(func() { activeTranscriber = orig })
+	activeTranscriber = transcriber.NewFake("hello", nil)
+
+	ctx, err := audio.NewFakeContext("test/data/short.wav", false)
+	if err != nil {
+		t.Fatalf("fake audio context: %v", err)
+	}
+	capture, err := ctx.NewCapture(nil, audio.CaptureConfig{
+		SampleRate: encoder.SampleRate, Channels: encoder.Channels,
+	})
+	if err != nil {
+		t.Fatalf("fake capture: %v", err)
+	}
+	sessions := make(chan recSession, 1)
+	loopDone := make(chan struct{})
+	go func() { recordSessions(func() audio.CaptureDevice { return capture }, sessions); close(loopDone) }()
+
+	path := filepath.Join(t.TempDir(), controlSocketName)
+	srv, err := listenControl(path, sessions, nil)
+	if err != nil {
+		t.Fatalf("listenControl: %v", err)
+	}
+	t.Cleanup(func() {
+		srv.Close()
+		close(sessions)
+		<-loopDone
+		capture.Close()
+		clip.SetLastText("")
+	})
+	return path
+}
+
+type controlClient struct {
+	t  *testing.T
+	c  net.Conn
+	sc *bufio.Scanner
+}
+
+func dialControl(t *testing.T, path string) *controlClient {
+	t.Helper()
+	c, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return &controlClient{t: t, c: c, sc: bufio.NewScanner(c)}
+}
+
+// read returns the next JSON line, failing the test after 5s.
+func (cc *controlClient) read() map[string]any {
+	cc.t.Helper()
+	cc.c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if !cc.sc.Scan() {
+		cc.t.Fatalf("read: %v", cc.sc.Err())
+	}
+	var m map[string]any
+	if err := json.Unmarshal(cc.sc.Bytes(), &m); err != nil {
+		cc.t.Fatalf("bad line %q: %v", cc.sc.Text(), err)
+	}
+	return m
+}
+
+func (cc *controlClient) do(cmd string) map[string]any {
+	cc.t.Helper()
+	if _, err := cc.c.Write([]byte(cmd + "\n")); err != nil {
+		cc.t.Fatalf("write: %v", err)
+	}
+	return cc.read()
+}
+
+// events reads events until one named last, returning their names in order.
+func (cc *controlClient) events(last string) ([]string, []map[string]any) {
+	cc.t.Helper()
+	var names []string
+	var evs []map[string]any
+	for {
+		ev := cc.read()
+		name, _ := ev["event"].(string)
+		names = append(names, name)
+		evs = append(evs, ev)
+		if name == last {
+			return names, evs
+		}
+	}
+}
+
+func TestControlStartStopEvents(t *testing.T) {
+	path := startControl(t)
+	sub := dialControl(t, path)
+	if r := sub.do("subscribe"); r["ok"] != true || r["state"] != "idle" {
+		t.Fatalf("subscribe = %v", r)
+	}
+
+	ctl := dialControl(t, path)
+	if r := ctl.do("start"); r["ok"] != true {
+		t.Fatalf("start = %v", r)
+	}
+	if r := ctl.do("start"); r["ok"] != false {
+		t.Fatalf("second start = %v, want busy", r)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if r := ctl.do("status"); r["state"] != "recording" || r["provider"] != "fake" {
+		t.Fatalf("status = %v", r)
+	}
+	if r := ctl.do("toggle"); r["ok"] != true {
+		t.Fatalf("toggle = %v", r)
+	}
+
+	names, evs := sub.events("idle")
+	want := []string{"recording_started", "transcribing", "text", "idle"}
+	if len(names) != len(want) {
+		t.Fatalf("events = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("events = %v, want %v", names, want)
+		}
+	}
+	if evs[2]["text"] != "hello" {
+		t.Errorf("text event = %v", evs[2])
+	}
+	if r := ctl.do("last"); r["text"] != "hello" {
+		t.Errorf("last = %v", r)
+	}
+	if r := ctl.do("status"); r["state"] != "idle" {
+		t.Errorf("status after cycle = %v", r)
+	}
+}
+
+func TestControlCancelDiscards(t *testing.T) {
+	path := startControl(t)
+	sub := dialControl(t, path)
+	sub.do("subscribe")
+	ctl := dialControl(t, path)
+
+	if r := ctl.do("cancel"); r["ok"] != false {
+		t.Fatalf("cancel while idle = %v", r)
+	}
+	ctl.do("start")
+	time.Sleep(150 * time.Millisecond)
+	if r := ctl.do("cancel"); r["ok"] != true {
+		t.Fatalf("cancel = %v", r)
+	}
+	names, _ := sub.events("idle")
+	for _, n := range names {
+		if n == "text" || n == "transcribing" {
+			t.Fatalf("cancelled recording was transcribed: %v", names)
+		}
+	}
+	if names[len(names)-2] != "cancelled" {
+		t.Errorf("events = %v, want cancelled before idle", names)
+	}
+	if r := ctl.do("last"); r["text"] != "" {
+		t.Errorf("last = %v, want nothing", r)
+	}
+}
+
+func TestControlRejects(t *testing.T) {
+	path := startControl(t)
+	ctl := dialControl(t, path)
+	for _, cmd := range []string{"bogus", "stop", "switch-model fake"} {
+		if r := ctl.do(cmd); r["ok"] != false || r["error"] == "" {
+			t.Errorf("%s = %v, want an error", cmd, r)
+		}
+	}
+	if _, err := listenControl(path, nil, nil); err == nil {
+		t.Error("a second listener took over a live socket")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"zee/log"
+	"zee/transcriber"
+)
+
+// fallbackHit is the outcome of a successful fallback: the transcript, and the
+// transcriber instance that produced it (its Name/GetModel are what the log,
+// the tray and the saved recording report).
+type fallbackHit struct {
+	result *transcriber.Result
+	tr     transcriber.Transcriber
+	ms     float64
+}
+
+// parseFallbackEntry splits one config.json "fallback" entry. "groq" means the
+// provider's default model; "groq:whisper-large-v3" pins one — the same
+// provider:model key the tray's modelIndex uses, since model IDs can contain
+// "/" (e.g. "Systran/faster-whisper-small").
+func parseFallbackEntry(entry string) (provider, model string) {
+	provider, model, _ = strings.Cut(strings.TrimSpace(entry), ":")
+	return provider, model
+}
+
+// transcribeFallback re-submits a failed dictation's audio to each provider in
+// the configured chain, in order, through the same direct-transcribe path as
+// -transcribe, and returns the first non-error result. Entries that can't help
+// are skipped rather than tried: unknown names, providers that aren't available
+// (no key), and the exact provider/model that just failed. Every attempt is
+// logged; the returned error joins them all.
+//
+// resolve is providerByName in the app; tests inject their own registry.
+func transcribeFallback(chain []string, resolve func(string) (transcriber.ProviderInfo, bool),
+	failed transcriber.Transcriber, audioData []byte, format, lang, hints string) (*fallbackHit, error) {
+
+	var errs []error
+	for _, entry := range chain {
+		name, model := parseFallbackEntry(entry)
+		p, ok := resolve(name)
+		if !ok {
+			log.Warnf("fallback: unknown provider %q, skipping", name)
+			continue
+		}
+		if model == "" && len(p.Models) > 0 {
+			model = p.Models[0].ID
+			if p.DefaultModel != "" {
+				model = p.DefaultModel
+			}
+		}
+		if failed != nil && name == failed.Name() && model == failed.GetModel() {
+			continue
+		}
+		if !p.Available() {
+			log.Info(fmt.Sprintf("fallback: %s not available, skipping", name))
+			continue
+		}
+
+		tr := p.New()
+		if model != "" {
+			tr.SetModel(model)
+		}
+		dt, ok := tr.(directTranscriber)
+		if !ok {
+			closeFallback(tr)
+			continue
+		}
+		start := time.Now()
+		res, err := dt.Transcribe(audioData, format, lang, hints)
+		ms := float64(time.Since(start).Microseconds()) / 1000
+		// Only local engines hold memory the GC can't reclaim (see applySwitch);
+		// a fallback instance is one-shot, so free it now.
+		closeFallback(tr)
+		if err != nil {
+			log.Warnf("fallback: %s/%s failed after %.0fms: %v", tr.Name(), tr.GetModel(), ms, err)
+			errs = append(errs, fmt.Errorf("%s: %w", tr.Name(), err))
+			continue
+		}
+		log.Info(fmt.Sprintf("fallback: %s/%s ok ms=%.0f", tr.Name(), tr.GetModel(), ms))
+		return &fallbackHit{result: res, tr: tr, ms: ms}, nil
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no fallback provider available")
+	}
+	return nil, errors.Join(errs...)
+}
+
+func closeFallback(tr transcriber.Transcriber) {
+	if c, ok := tr.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
+// fallbackSessionResult folds a fallback transcript into the failed session's
+// result, so the rest of finishTranscription (paste, metrics, last recording)
+// runs exactly as for a first-try success. The audio stays the session's own;
+// the batch stats describe the fallback request.
+func fallbackSessionResult(failed transcriber.SessionResult, hit *fallbackHit, recDur time.Duration) transcriber.SessionResult {
+	text := strings.TrimSpace(hit.result.Text)
+	sr := transcriber.SessionResult{
+		Text:         text,
+		HasText:      text != "",
+		NoSpeech:     text == "",
+		RateLimit:    hit.result.RateLimit,
+		ProcessRSSMB: failed.ProcessRSSMB,
+		AudioData:    failed.AudioData,
+		AudioFormat:  failed.AudioFormat,
+		Batch: &transcriber.BatchStats{
+			AudioLengthS: recDur.Seconds(),
+			TotalTimeMs:  hit.ms,
+			InferenceMs:  hit.result.InferenceMs,
+			Confidence:   hit.result.Confidence,
+		},
+	}
+	if m := hit.result.Metrics; m != nil {
+		sr.Batch.DNSTimeMs = float64(m.DNS.Milliseconds())
+		sr.Batch.TLSTimeMs = float64(m.TLS.Milliseconds())
+		sr.Batch.TTFBMs = float64(m.TTFB.Milliseconds())
+		sr.Batch.ConnReused = m.ConnReused
+		sr.Batch.TLSProtocol = m.TLSProtocol
+		sr.Batch.Retries = len(m.Retries)
+		sr.Batch.RetryMs = float64(m.RetryCost().Milliseconds())
+	}
+	return sr
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"zee/transcriber"
+)
+
+// TestTranscribeFallbackOrder pins the chain walk: entries that can't help are
+// skipped without a request, failing providers are passed over, and the first
+// success wins — before any later entry is touched.
+func TestTranscribeFallbackOrder(t *testing.T) {
+	var tried []string
+	provider := func(name string, local, available bool, err error) transcriber.ProviderInfo {
+		return transcriber.ProviderInfo{
+			Name:      name,
+			Local:     local,
+			Available: func() bool { return available },
+			New: func() transcriber.Transcriber {
+				tried = append(tried, name)
+				return transcriber.NewFake("from "+name, err)
+			},
+		}
+	}
+	registry := map[string]transcriber.ProviderInfo{
+		"nokey": provider("nokey", false, false, nil),
+		"down":  provider("down", false, true, errors.New("503")),
+		"local": provider("local", true, true, errors.New("model not downloaded")),
+		"good":  provider("good", false, true, nil),
+		"late":  provider("late", false, true, nil),
+		"fake":  provider("fake", false, true, nil),
+	}
+	resolve := func(name string) (transcriber.ProviderInfo, bool) {
+		p, ok := registry[name]
+		return p, ok
+	}
+
+	failed := transcriber.NewFake("", errors.New("dns")) // Name "fake", model ""
+	chain := []string{"unknown", "fake", "nokey", "down", "local", "good", "late"}
+	hit, err := transcribeFallback(chain, resolve, failed, []byte("AUDIO"), "flac", "en", "")
+	if err != nil {
+		t.Fatalf("transcribeFallback: %v", err)
+	}
+	if hit.result.Text != "from good" {
+		t.Errorf("text = %q, want the first working provider's", hit.result.Text)
+	}
+	if got := strings.Join(tried, ","); got != "down,local,good" {
+		t.Errorf("providers tried = %s, want down,local,good (unknown, the failed one and keyless skipped)", got)
+	}
+}
+
+func TestTranscribeFallbackAllFail(t *testing.T) {
+	resolve := func(name string) (transcriber.ProviderInfo, bool) {
+		return transcriber.ProviderInfo{
+			Name:      name,
+			Available: func() bool { return true },
+			New:       func() transcriber.Transcriber { return transcriber.NewFake("", errors.New(name+" down")) },
+		}, true
+	}
+	_, err := transcribeFallback([]string{"a", "b"}, resolve, nil, []byte("AUDIO"), "mp3", "", "")
+	if err == nil || !strings.Contains(err.Error(), "a down") || !strings.Contains(err.Error(), "b down") {
+		t.Errorf("err = %v, want both providers' errors", err)
+	}
+
+	if _, err := transcribeFallback(nil, resolve, nil, nil, "mp3", "", ""); err == nil {
+		t.Error("an empty chain must report failure")
+	}
+}
+
+func TestParseFallbackEntry(t *testing.T) {
+	for _, tt := range []struct{ in, provider, model string }{
+		{"groq", "groq", ""},
+		{" openai:gpt-4o-transcribe ", "openai", "gpt-4o-transcribe"},
+		{"compatible:Systran/faster-whisper-small", "compatible", "Systran/faster-whisper-small"},
+	} {
+		p, m := parseFallbackEntry(tt.in)
+		if p != tt.provider || m != tt.model {
+			t.Errorf("parseFallbackEntry(%q) = %q, %q; want %q, %q", tt.in, p, m, tt.provider, tt.model)
+		}
+	}
+}
@@ -9,12 +9,14 @@ require (
 	github.com/atotto/clipboard v0.1.4
 	github.com/energye/systray v1.0.3
 	github.com/gen2brain/malgo v0.11.25-0.20251120102819-856f60956a65
+	github.com/hajimehoshi/go-mp3 v0.3.4
 	github.com/jfreymuth/pulse v0.1.1
 	github.com/maxhawkins/go-webrtcvad v0.0.0-20210121163624-be60036f3083
 	github.com/mewkiz/flac v1.0.13
 	github.com/rs/zerolog v1.34.0
 	github.com/shirou/gopsutil/v4 v4.26.5
 	golang.design/x/hotkey v0.4.1
+	golang.org/x/sys v0.42.0
 	golang.org/x/term v0.39.0
 	nhooyr.io/websocket v1.8.17
 )
@@ -54,5 +56,4 @@ require (
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
 	golang.design/x/mainthread v0.3.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
-	golang.org/x/sys v0.42.0 // indirect
 )
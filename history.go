@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"zee/clipboard"
+	"zee/config"
+	"zee/history"
+	"zee/log"
+	"zee/tray"
+)
+
+// With config.json's history on, every delivered dictation goes into the
+// history store (package history) and the newest few into the tray's Recent
+// submenu, where picking one pastes it again. `zee history` reads the store.
+
+func historyStore() *history.Store {
+	h := config.Get().History
+	return history.Open(filepath.Join(config.Dir(), history.FileName), history.Retention{
+		MaxEntries: h.MaxEntries,
+		MaxAge:     time.Duration(h.MaxDays) * 24 * time.Hour,
+	})
+}
+
+// addHistory stores e and refreshes Recent, returning its ID (0 when history
+// is off or the write failed — a lost entry must not cost the paste).
+func addHistory(e history.Entry) int {
+	if !config.Get().History.Enabled {
+		return 0
+	}
+	e, err := historyStore().Add(e)
+	if err != nil {
+		log.Warnf("history: %v", err)
+		return 0
+	}
+	refreshRecent()
+	return e.ID
+}
+
+// linkHistoryAudio notes on entry id where its recording was saved.
+func linkHistoryAudio(id int, dir string) {
+	if id == 0 {
+		return
+	}
+	if err := historyStore().Link(id, dir); err != nil {
+		log.Warnf("history: link audio: %v", err)
+	}
+}
+
+var (
+	recentMu      sync.Mutex
+	recentEntries []history.Entry
+)
+
+// refreshRecent reloads the tray's Recent submenu from the store.
+func refreshRecent() {
+	var entries []history.Entry
+	if config.Get().History.Enabled {
+		var err error
+		if entries, err = historyStore().Recent(tray.MaxRecent); err != nil {
+			log.Warnf("history: %v", err)
+		}
+	}
+	titles := make([]string, len(entries))
+	for i, e := range entries {
+		titles[i] = e.Time.Format("15:04") + "  " + oneLine(e.Text, 48)
+	}
+	recentMu.Lock()
+	recentEntries = entries
+	recentMu.Unlock()
+	tray.SetRecent(titles, pasteRecent)
+}
+
+// pasteRecent is a Recent pick: the entry's text goes where a dictation
+// would — pasted, clipboard restored after, or only copied with auto-paste
+// off.
+func pasteRecent(i int) {
+	recentMu.Lock()
+	if i >= len(recentEntries) {
+		recentMu.Unlock()
+		return
+	}
+	text := recentEntries[i].Text
+	recentMu.Unlock()
+	configMu.Lock()
+	paste := autoPaste
+	configMu.Unlock()
+	if !paste {
+		clipboard.Copy(text)
+		return
+	}
+	clip.CancelRestore()
+	prev := clip.SaveCurrent()
+	clip.PasteText(text)
+	clip.ScheduleRestore(prev)
+}
+
+// oneLine is text on one line, cut to n runes.
+func oneLine(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return text
+}
+
+const historyUsage = `Usage:
+  zee history [list] [-n N]           the last N dictations (default 20)
+  zee history search <words>...       dictations containing every word
+  zee history show <id>               one dictation in full
+  zee history copy <id>               copy a dictation's text to the clipboard
+  zee history export [-format json|csv|md] [-o file] [words...]
+`
+
+// runHistory is the `zee history` verb. It returns the exit code.
+func runHistory(args []string, stdout, stderr io.Writer) int {
+	sub := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("zee history "+sub, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, historyUsage) }
+	n := fs.Int("n", 20, "entries to list")
+	format := fs.String("format", "json", "export format: "+strings.Join(history.Formats, ", "))
+	outPath := fs.String("o", "", "export to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	store := historyStore()
+
+	entryArg := func() (history.Entry, error) {
+		id, err := strconv.Atoi(strings.TrimPrefix(fs.Arg(0), "#"))
+		if fs.NArg() != 1 || err != nil {
+			return history.Entry{}, fmt.Errorf("zee history %s takes one entry id", sub)
+		}
+		return store.Get(id)
+	}
+
+	var err error
+	switch sub {
+	case "list", "search":
+		var entries []history.Entry
+		if sub == "list" {
+			entries, err = store.Recent(*n)
+			slices.Reverse(entries)
+		} else if fs.NArg() == 0 {
+			err = errors.New("zee history search needs words to look for")
+		} else {
+			entries, err = store.Search(strings.Join(fs.Args(), " "))
+		}
+		for _, e := range entries {
+			fmt.Fprintf(stdout, "%4d  %s  %-32s %5.1fs  %s\n", e.ID, e.Time.Format("2006-01-02 15:04"),
+				oneLine(e.Source(), 32), e.DurationS, oneLine(e.Text, 60))
+		}
+		if err == nil && len(entries) == 0 {
+			fmt.Fprintln(stderr, "no dictations found")
+		}
+	case "show":
+		var e history.Entry
+		if e, err = entryArg(); err == nil {
+			fmt.Fprintf(stdout, "#%d  %s\n", e.ID, e.Time.Format("2006-01-02 15:04:05"))
+			fmt.Fprintf(stdout, "source    %s\n", e.Source())
+			fmt.Fprintf(stdout, "timing    %.1fs audio, %.0f ms to text\n", e.DurationS, e.LatencyMs)
+			if e.Audio != "" {
+				fmt.Fprintf(stdout, "audio     %s\n", e.Audio)
+			}
+			fmt.Fprintf(stdout, "\n%s\n", e.Text)
+		}
+	case "copy":
+		var e history.Entry
+		if e, err = entryArg(); err == nil {
+			err = clipboard.Copy(e.Text)
+		}
+	case "export":
+		var entries []history.Entry
+		if fs.NArg() > 0 {
+			entries, err = store.Search(strings.Join(fs.Args(), " "))
+		} else {
+			entries, err = store.List()
+		}
+		if err == nil {
+			err = exportHistory(entries, *format, *outPath, stdout)
+		}
+	default:
+		fmt.Fprint(stderr, historyUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "zee history: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func exportHistory(entries []history.Entry, format, path string, stdout io.Writer) error {
+	if path == "" {
+		return history.Export(stdout, entries, format)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	return errors.Join(history.Export(f, entries, format), f.Close())
+}
@@ -0,0 +1,67 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats are the export formats, by name.
+var Formats = []string{"json", "csv", "md"}
+
+// Export writes entries in format: a JSON array, CSV with a header row, or
+// Markdown with a section per entry.
+func Export(w io.Writer, entries []Entry, format string) error {
+	switch format {
+	case "json":
+		if entries == nil {
+			entries = []Entry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id", "time", "provider", "model", "language", "duration_s", "latency_ms", "text", "audio"})
+		for _, e := range entries {
+			cw.Write([]string{
+				strconv.Itoa(e.ID),
+				e.Time.Format(time.RFC3339),
+				e.Provider,
+				e.Model,
+				e.Language,
+				strconv.FormatFloat(e.DurationS, 'f', 1, 64),
+				strconv.FormatFloat(e.LatencyMs, 'f', 0, 64),
+				e.Text,
+				e.Audio,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "md":
+		for i, e := range entries {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "## %s\n\n", e.Time.Format("2006-01-02 15:04:05"))
+			fmt.Fprintf(w, "*#%d · %s · %.1fs · %.0f ms*\n\n", e.ID, e.Source(), e.DurationS, e.LatencyMs)
+			fmt.Fprintln(w, strings.TrimSpace(e.Text))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown export format %q (use json, csv or md)", format)
+}
+
+// Source is "provider/model", with the language when one was set.
+func (e Entry) Source() string {
+	s := e.Provider + "/" + e.Model
+	if e.Language != "" {
+		s += " (" + e.Language + ")"
+	}
+	return s
+}
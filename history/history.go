@@ -0,0 +1,223 @@
+// Package history keeps every finished dictation — text, provider, model,
+// timings, a link to its saved audio — in history.jsonl, one JSON entry per
+// line, oldest first. The app appends; `zee history` reads. Retention is
+// applied on append, so the file never outgrows the configured limits by more
+// than the entry being written.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the store's name in the config dir.
+const FileName = "history.jsonl"
+
+// Entry is one dictation.
+type Entry struct {
+	ID        int       `json:"id"`
+	Time      time.Time `json:"time"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Language  string    `json:"language,omitempty"` // "" is auto-detect
+	DurationS float64   `json:"duration_s"`         // audio length
+	LatencyMs float64   `json:"latency_ms"`         // release → text
+	Text      string    `json:"text"`               // as delivered, after post-processing
+	Audio     string    `json:"audio,omitempty"`    // folder of the saved recording, when one was kept
+}
+
+// Retention bounds the store. Zero fields don't limit.
+type Retention struct {
+	MaxEntries int
+	MaxAge     time.Duration
+}
+
+// Store is history.jsonl. Its methods are safe for concurrent use within a
+// process, across Stores too; only the app writes.
+type Store struct {
+	path string
+	ret  Retention
+}
+
+// mu serializes every Store's reads and rewrites. The app opens a Store per
+// use, with the retention config.json holds at the time, so a lock on the
+// Store would guard nothing: a Link rewriting from a stale read would drop
+// an Add made meanwhile through another.
+var mu sync.Mutex
+
+func Open(path string, ret Retention) *Store {
+	return &Store{path: path, ret: ret}
+}
+
+func (s *Store) Path() string { return s.path }
+
+// read loads every entry, skipping lines that don't parse (a torn last line
+// after a crash, a hand edit). A missing file is an empty history.
+func (s *Store) read() ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) == nil && e.ID > 0 {
+			entries = append(entries, e)
+		}
+	}
+	return entries, sc.Err()
+}
+
+// rewrite replaces the file with entries, atomically: a reader never sees it
+// half-written.
+func (s *Store) rewrite(entries []Entry) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), FileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := errors.Join(w.Flush(), tmp.Chmod(0600), tmp.Close()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Add stores e under the next ID, dropping whatever retention no longer
+// allows, and returns it as stored.
+func (s *Store) Add(e Entry) (Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	entries, err := s.read()
+	if err != nil {
+		return Entry{}, err
+	}
+	e.ID = 1
+	if len(entries) > 0 {
+		e.ID = entries[len(entries)-1].ID + 1
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	kept := s.ret.apply(entries, e.Time)
+	if len(kept) < len(entries) {
+		return e, s.rewrite(append(kept, e))
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return Entry{}, err
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return Entry{}, err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	return e, errors.Join(enc.Encode(e), f.Close())
+}
+
+// apply returns the entries retention keeps once one more is added at now.
+func (r Retention) apply(entries []Entry, now time.Time) []Entry {
+	if r.MaxAge > 0 {
+		i := slices.IndexFunc(entries, func(e Entry) bool { return now.Sub(e.Time) <= r.MaxAge })
+		if i < 0 {
+			i = len(entries)
+		}
+		entries = entries[i:]
+	}
+	if r.MaxEntries > 0 && len(entries) >= r.MaxEntries {
+		entries = entries[len(entries)-r.MaxEntries+1:]
+	}
+	return entries
+}
+
+// Link records where entry id's audio was saved.
+func (s *Store) Link(id int, audio string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(entries, func(e Entry) bool { return e.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+	entries[i].Audio = audio
+	return s.rewrite(entries)
+}
+
+var ErrNotFound = errors.New("no such history entry")
+
+// List returns every entry, oldest first.
+func (s *Store) List() ([]Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return s.read()
+}
+
+// Recent returns the last n entries, newest first.
+func (s *Store) Recent(n int) ([]Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	entries = entries[max(0, len(entries)-n):]
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// Get returns entry id.
+func (s *Store) Get(id int) (Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return Entry{}, err
+	}
+	i := slices.IndexFunc(entries, func(e Entry) bool { return e.ID == id })
+	if i < 0 {
+		return Entry{}, ErrNotFound
+	}
+	return entries[i], nil
+}
+
+// Search returns the entries whose text holds every word of query, in any
+// case and order, oldest first.
+func (s *Store) Search(query string) ([]Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	terms := strings.Fields(strings.ToLower(query))
+	return slices.DeleteFunc(entries, func(e Entry) bool {
+		text := strings.ToLower(e.Text)
+		for _, t := range terms {
+			if !strings.Contains(text, t) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
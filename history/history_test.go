@@ -0,0 +1,148 @@
+package history
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func add(t *testing.T, s *Store, text string, at time.Time) Entry {
+	t.Helper()
+	e, err := s.Add(Entry{Time: at, Provider: "groq", Model: "whisper", Text: text})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func texts(entries []Entry) string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Text)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestStore(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), FileName), Retention{})
+	now := time.Now()
+	add(t, s, "Ship it on Friday", now)
+	add(t, s, "call Anna about the invoice", now)
+	if e := add(t, s, "friday standup moved", now); e.ID != 3 {
+		t.Fatalf("third ID = %d", e.ID)
+	}
+
+	if got, _ := s.Recent(2); texts(got) != "friday standup moved,call Anna about the invoice" {
+		t.Errorf("Recent = %s", texts(got))
+	}
+	if got, _ := s.Search("FRIDAY"); texts(got) != "Ship it on Friday,friday standup moved" {
+		t.Errorf("Search friday = %s", texts(got))
+	}
+	if got, _ := s.Search("anna invoice"); len(got) != 1 || got[0].ID != 2 {
+		t.Errorf("Search anna invoice = %v", got)
+	}
+	if err := s.Link(2, "/samples/x"); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := s.Get(2); e.Audio != "/samples/x" {
+		t.Errorf("linked entry = %+v", e)
+	}
+	if _, err := s.Get(9); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(9) err = %v", err)
+	}
+
+	// A torn line (a crash mid-append) costs that line only, and IDs go on.
+	f, _ := os.OpenFile(s.Path(), os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"id":4,"text":"tor`)
+	f.Close()
+	if e := add(t, s, "after", now); e.ID != 4 {
+		t.Errorf("ID after torn line = %d", e.ID)
+	}
+	if info, _ := os.Stat(s.Path()); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v", info.Mode().Perm())
+	}
+}
+
+// TestStoresShareLock: Stores opened separately on one file, as the app
+// opens them, never lose each other's writes.
+func TestStoresShareLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	first := add(t, Open(path, Retention{}), "first", time.Now())
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			Open(path, Retention{}).Add(Entry{Text: fmt.Sprint(i)})
+		}()
+		go func() {
+			defer wg.Done()
+			Open(path, Retention{}).Link(first.ID, fmt.Sprint("samples/", i))
+		}()
+	}
+	wg.Wait()
+	entries, err := Open(path, Retention{}).List()
+	if err != nil || len(entries) != 21 {
+		t.Fatalf("%d entries, %v; want 21", len(entries), err)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), FileName), Retention{MaxEntries: 3, MaxAge: 24 * time.Hour})
+	now := time.Now()
+	add(t, s, "old", now.Add(-48*time.Hour))
+	add(t, s, "a", now.Add(-time.Hour))
+	add(t, s, "b", now)
+	if got, _ := s.List(); texts(got) != "a,b" {
+		t.Errorf("after age cut = %s", texts(got))
+	}
+	add(t, s, "c", now)
+	add(t, s, "d", now)
+	if got, _ := s.List(); texts(got) != "b,c,d" {
+		t.Errorf("after count cut = %s", texts(got))
+	}
+}
+
+func TestExport(t *testing.T) {
+	entries := []Entry{{ID: 1, Time: time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC), Provider: "groq", Model: "w",
+		Language: "en", DurationS: 2.5, LatencyMs: 410, Text: "Hello, \"world\"\nsecond line"}}
+
+	var b bytes.Buffer
+	if err := Export(&b, entries, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var back []Entry
+	if err := json.Unmarshal(b.Bytes(), &back); err != nil || len(back) != 1 || back[0].Text != entries[0].Text {
+		t.Errorf("json round trip = %v, %v", back, err)
+	}
+
+	b.Reset()
+	Export(&b, entries, "csv")
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil || len(rows) != 2 || rows[0][7] != "text" || rows[1][7] != entries[0].Text || rows[1][5] != "2.5" {
+		t.Errorf("csv = %q, %v", rows, err)
+	}
+
+	b.Reset()
+	Export(&b, entries, "md")
+	if md := b.String(); !strings.HasPrefix(md, "## 2026-10-17 09:30:00\n") || !strings.Contains(md, "groq/w (en)") {
+		t.Errorf("md = %q", md)
+	}
+
+	if err := Export(&b, nil, "xml"); err == nil {
+		t.Error("unknown format accepted")
+	}
+	b.Reset()
+	Export(&b, nil, "json")
+	if strings.TrimSpace(b.String()) != "[]" {
+		t.Errorf("empty json = %q", b.String())
+	}
+}
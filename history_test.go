@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"zee/config"
+	"zee/history"
+)
+
+func TestRunHistory(t *testing.T) {
+	prev := config.Dir()
+	config.SetDir(t.TempDir())
+	t.Cleanup(func() { config.SetDir(prev) })
+	config.Load()
+	store := historyStore()
+	at := time.Date(2026, 10, 17, 9, 30, 0, 0, time.Local)
+	for _, text := range []string{"Ship it on Friday.", "Call Anna about the invoice.", "Standup moved to Friday."} {
+		if _, err := store.Add(history.Entry{Time: at, Provider: "groq", Model: "whisper", DurationS: 2, Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(args ...string) (string, string, int) {
+		var out, errOut bytes.Buffer
+		code := runHistory(args, &out, &errOut)
+		return out.String(), errOut.String(), code
+	}
+	if out, _, code := run("-n", "2"); code != 0 || strings.Count(out, "\n") != 2 ||
+		strings.Index(out, "Anna") > strings.Index(out, "Standup") {
+		t.Errorf("list -n 2 = %d, %q", code, out)
+	}
+	if out, _, _ := run("search", "friday"); !strings.Contains(out, "   1  ") || !strings.Contains(out, "   3  ") || strings.Contains(out, "Anna") {
+		t.Errorf("search friday = %q", out)
+	}
+	if out, _, code := run("show", "#2"); code != 0 || !strings.Contains(out, "groq/whisper") || !strings.HasSuffix(out, "\nCall Anna about the invoice.\n") {
+		t.Errorf("show 2 = %d, %q", code, out)
+	}
+	if _, errOut, code := run("show", "9"); code != 1 || !strings.Contains(errOut, "no such") {
+		t.Errorf("show 9 = %d, %q", code, errOut)
+	}
+	out, _, code := run("export", "-format", "csv", "friday")
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if code != 0 || err != nil || len(rows) != 3 {
+		t.Errorf("export csv friday = %d, %q, %v", code, out, err)
+	}
+	if _, _, code := run("frobnicate"); code != 2 {
+		t.Errorf("unknown subcommand exit = %d", code)
+	}
+}
+
+func TestOneLine(t *testing.T) {
+	if got := oneLine("Hello\nthere,   world", 11); got != "Hello ther…" {
+		t.Errorf("oneLine = %q", got)
+	}
+}
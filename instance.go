@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"zee/config"
+)
+
+// One zee app per user: two would both grab the hotkey, both open the mic and
+// both paste. The app holds an OS lock on zee.lock in the config dir — flock
+// on Linux and macOS, LockFileEx on Windows — which the kernel drops however
+// the process ends, so neither a crash nor a recycled PID leaves zee locked
+// out, and two launches racing for it can't both win. The file names the
+// holder's PID and start time; a second launch reads it and forwards its
+// intent over the control socket instead of starting.
+//
+// Only the app takes the lock. `zee transcribe`, `watch`, `serve`, -test and
+// the benchmark touch neither hotkey nor mic and run alongside it.
+
+const instanceLockName = "zee.lock"
+
+func instanceLockPath() string { return filepath.Join(config.Dir(), instanceLockName) }
+
+var errInstanceRunning = errors.New("zee is already running")
+
+// errLockHeld is tryLockFile's answer when another open file holds the lock.
+var errLockHeld = errors.New("lock held")
+
+// instanceLock is a held lock; its token is what this process wrote, and f
+// the open file the OS lock lives on.
+type instanceLock struct {
+	path  string
+	token string
+	f     *os.File
+}
+
+// appLock is the running app's lock, released on shutdown.
+var appLock *instanceLock
+
+// acquireInstance takes the lock at path. When a live zee holds it, it
+// returns that zee's PID with errInstanceRunning.
+func acquireInstance(path string) (*instanceLock, int, error) {
+	start, err := processStartTime(os.Getpid())
+	if err != nil {
+		return nil, 0, fmt.Errorf("own start time: %w", err)
+	}
+	token := fmt.Sprintf("%d %s", os.Getpid(), start)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, 0, err
+	}
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := tryLockFile(f); err != nil {
+			f.Close()
+			if errors.Is(err, errLockHeld) {
+				return nil, lockOwner(path), errInstanceRunning
+			}
+			return nil, 0, err
+		}
+		// The previous holder removes the file on release; if it did so
+		// between our open and our lock, we locked a file nobody else will
+		// ever open. Go again on whatever is at path now.
+		if held, err := f.Stat(); err == nil {
+			if cur, err := os.Stat(path); err == nil && os.SameFile(held, cur) {
+				l := &instanceLock{path: path, token: token, f: f}
+				if err := l.write(); err != nil {
+					l.f.Close()
+					return nil, 0, err
+				}
+				return l, 0, nil
+			}
+		}
+		f.Close()
+	}
+}
+
+// write replaces whatever a previous holder left in the file with our token.
+func (l *instanceLock) write() error {
+	if err := l.f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := l.f.WriteAt([]byte(l.token+"\n"), 0)
+	return err
+}
+
+// lockOwner reads the PID the lock at path names; 0 when it can't be read —
+// its holder may not have written it yet.
+func lockOwner(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pidStr, _, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	return pid
+}
+
+// Release removes the lock file if it is still this process's, then drops the
+// OS lock. Removing first means a launch that opens the path from then on
+// creates a fresh file, and one that already had it open fails the same-file
+// check in acquireInstance. Windows refuses to remove an open file, so there
+// the removal is retried once the handle is closed.
+func (l *instanceLock) Release() {
+	data, err := os.ReadFile(l.path)
+	ours := err == nil && strings.TrimSpace(string(data)) == l.token
+	removed := ours && os.Remove(l.path) == nil
+	l.f.Close()
+	if ours && !removed && runtime.GOOS == "windows" {
+		os.Remove(l.path)
+	}
+}
+
+// controlVerbs are the subcommands that drive a running app over the control
+// socket. Launched with no app running, start and toggle launch it and begin
+// recording; the rest report that nothing is running.
+var controlVerbs = map[string]bool{
+	"start": true, "stop": true, "toggle": true, "cancel": true,
+	"status": true, "last": true, "switch-model": true,
+}
+
+// forwardToInstance sends cmd (a control command line; "" for a bare launch,
+// which asks for status) to the app at sock and prints the answer. The owner
+// may still be starting up, so the socket gets a few seconds to appear.
+// Returns the exit code.
+func forwardToInstance(sock string, owner int, cmd string, out io.Writer) int {
+	bare := cmd == ""
+	if bare {
+		cmd = "status"
+	}
+	var (
+		resp map[string]any
+		err  error
+	)
+	for deadline := time.Now().Add(3 * time.Second); ; time.Sleep(100 * time.Millisecond) {
+		resp, err = controlRequest(sock, cmd)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(out, "zee is already running (pid %d) but not answering on %s: %v\n", owner, sock, err)
+		return 1
+	}
+	if ok, _ := resp["ok"].(bool); !ok {
+		fmt.Fprintf(out, "zee: %v\n", resp["error"])
+		return 1
+	}
+	switch verb, _, _ := strings.Cut(cmd, " "); verb {
+	case "status":
+		if bare {
+			fmt.Fprintf(out, "zee is already running (pid %d).\n", owner)
+		}
+		fmt.Fprintf(out, "%v: %v/%v", resp["state"], resp["provider"], resp["model"])
+		if lang, _ := resp["language"].(string); lang != "" {
+			fmt.Fprintf(out, " (%s)", lang)
+		}
+		fmt.Fprintln(out)
+	case "last":
+		fmt.Fprintln(out, resp["text"])
+	case "switch-model":
+		fmt.Fprintf(out, "switched to %v/%v\n", resp["provider"], resp["model"])
+	}
+	return 0
+}
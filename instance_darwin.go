@@ -0,0 +1,26 @@
+//go:build darwin
+
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// processStartTime identifies pid's current process by its start time, from
+// the kernel's process table. An error means no such process.
+func processStartTime(pid int) (string, error) {
+	k, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return "", err
+	}
+	if int(k.Proc.P_pid) != pid {
+		return "", fmt.Errorf("no process %d", pid)
+	}
+	if k.Proc.P_stat == 5 { // SZOMB
+		return "", fmt.Errorf("process %d has exited", pid)
+	}
+	t := k.Proc.P_starttime
+	return fmt.Sprintf("%d.%06d", t.Sec, t.Usec), nil
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// processStartTime identifies pid's current process: its start time in clock
+// ticks since boot (field 22 of /proc/<pid>/stat). An error means no such
+// process.
+func processStartTime(pid int) (string, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", err
+	}
+	// comm (field 2) is parenthesised and may itself hold spaces or parens.
+	i := strings.LastIndexByte(string(stat), ')')
+	if i < 0 {
+		return "", fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	f := strings.Fields(string(stat[i+1:]))
+	if len(f) < 20 {
+		return "", fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	if f[0] == "Z" {
+		return "", fmt.Errorf("process %d has exited", pid)
+	}
+	return f[19], nil // fields 3.. follow comm, so 22 is index 19
+}
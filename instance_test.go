@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestInstanceLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), instanceLockName)
+	lock, _, err := acquireInstance(path)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	// This process holds it, so a second acquire sees a live owner.
+	if _, owner, err := acquireInstance(path); !errors.Is(err, errInstanceRunning) || owner != os.Getpid() {
+		t.Fatalf("second acquire = %d, %v; want pid %d, errInstanceRunning", owner, err, os.Getpid())
+	}
+	lock.Release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("lock survived Release: %v", err)
+	}
+}
+
+func TestInstanceLockStale(t *testing.T) {
+	start, err := processStartTime(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"crashed":    "999999999 12345\n",
+		"pid reused": fmt.Sprintf("%d %s0\n", os.Getpid(), start), // our PID, another start
+		"garbage":    "\x00\x00",
+	} {
+		path := filepath.Join(t.TempDir(), instanceLockName)
+		os.WriteFile(path, []byte(content), 0600)
+		lock, _, err := acquireInstance(path)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		data, _ := os.ReadFile(path)
+		if !strings.HasPrefix(string(data), fmt.Sprint(os.Getpid())+" "+start) {
+			t.Errorf("%s: lock = %q", name, data)
+		}
+		// Release leaves a lock another process has since taken alone.
+		os.WriteFile(path, []byte(content), 0600)
+		lock.Release()
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s: Release removed a lock it doesn't own", name)
+		}
+	}
+}
+
+// TestInstanceLockRace: launches racing for a stale or just-released lock
+// never both win.
+func TestInstanceLockRace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), instanceLockName)
+	os.WriteFile(path, []byte("999999999 12345\n"), 0600)
+	for range 50 {
+		var (
+			mu   sync.Mutex
+			held []*instanceLock
+			wg   sync.WaitGroup
+		)
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				lock, _, err := acquireInstance(path)
+				if err == nil {
+					mu.Lock()
+					held = append(held, lock)
+					mu.Unlock()
+				} else if !errors.Is(err, errInstanceRunning) {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		if len(held) != 1 {
+			t.Fatalf("%d launches took the lock, want 1", len(held))
+		}
+		held[0].Release()
+	}
+}
+
+func TestForwardToInstance(t *testing.T) {
+	path := startControl(t)
+	var out bytes.Buffer
+	if code := forwardToInstance(path, 42, "", &out); code != 0 ||
+		!strings.Contains(out.String(), "already running (pid 42)") || !strings.Contains(out.String(), "idle: fake/") {
+		t.Fatalf("bare launch = %d, %q", code, out.String())
+	}
+	out.Reset()
+	if code := forwardToInstance(path, 42, "stop", &out); code != 1 || !strings.Contains(out.String(), "not recording") {
+		t.Fatalf("stop = %d, %q", code, out.String())
+	}
+	out.Reset()
+	if code := forwardToInstance(filepath.Join(t.TempDir(), "none.sock"), 42, "status", &out); code != 1 ||
+		!strings.Contains(out.String(), "not answering") {
+		t.Fatalf("dead socket = %d, %q", code, out.String())
+	}
+}
@@ -0,0 +1,46 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// processStartTime identifies pid's current process by its creation time. An
+// error means no such process.
+func processStartTime(pid int) (string, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(h)
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return "", err
+	}
+	if code != 259 { // STILL_ACTIVE
+		return "", fmt.Errorf("process %d has exited", pid)
+	}
+	var created, exited, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(h, &created, &exited, &kernel, &user); err != nil {
+		return "", err
+	}
+	return fmt.Sprint(created.Nanoseconds()), nil
+}
+
+// tryLockFile takes an exclusive LockFileEx lock without waiting; errLockHeld
+// if another handle has it. Windows locks are mandatory, so the locked byte
+// sits at 4 GiB, far past the PID, which other launches must still read.
+func tryLockFile(f *os.File) error {
+	ol := &windows.Overlapped{OffsetHigh: 1}
+	err := windows.LockFileEx(windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errLockHeld
+	}
+	return err
+}
@@ -24,11 +24,10 @@ import (
 //	make bench-local WAV=~/clips/a.wav    # one custom clip
 //	make bench-local WAV=~/Library/Application\ Support/zee/samples   # a whole dir
 //
-// Clips come from ZEE_BENCH_WAV (a file or a directory scanned for *.wav) and
-// must be 16 kHz mono 16-bit — WAVToPCM rejects anything else, and such a clip
-// is skipped rather than benchmarked wrong. Saved samples from the local engine
-// are already in that format; ones saved from a cloud provider are .mp3 and are
-// ignored by the *.wav scan.
+// Clips come from ZEE_BENCH_WAV (a file or a directory scanned for *.wav); any
+// PCM or float WAV works, as WAVToPCM converts it to 16 kHz mono 16-bit, and a
+// clip it can't read is skipped rather than benchmarked wrong. Saved samples
+// from a cloud provider are .mp3 and are ignored by the *.wav scan.
 //
 // Each (clip, model) pair is its own sub-benchmark — BenchmarkTranscribe/<clip>/<model>
 // — so `benchstat old.txt new.txt` lines the same clip up across parakeet.cpp or
@@ -87,7 +86,7 @@ func clipName(path string) string {
 }
 
 // loadPCM reads a wav and converts it to the engine's float32 samples. Format
-// validation is WAVToPCM's (16 kHz mono 16-bit); anything else skips the clip.
+// handling is WAVToPCM's; a wav it rejects skips the clip.
 func loadPCM(tb testing.TB, path string) []float32 {
 	raw, err := os.ReadFile(path)
 	if err != nil {
@@ -35,6 +35,8 @@ type Metrics struct {
 	ProcessRSSMB     float64
 	InferenceMs      float64
 	PressToRecordMs  float64 // press → mic-live; a stall here (fork/lock) misses quick taps
+	Retries          int     // HTTP retries before the answering attempt; total_ms excludes them
+	RetryMs          float64 // what the retries cost: failed attempts + backoff
 }
 
 func ResolveDir(flagPath string) (string, error) {
@@ -218,6 +220,9 @@ func TranscriptionMetrics(m Metrics, mode, format, provider string, connReused b
 	if m.PressToRecordMs > 0 {
 		ev = ev.Float64("press_to_record_ms", m.PressToRecordMs)
 	}
+	if m.Retries > 0 {
+		ev = ev.Int("retries", m.Retries).Float64("retry_ms", m.RetryMs)
+	}
 	ev.Msg("transcription")
 }
 
@@ -244,15 +249,18 @@ type LatencyBreakdown struct {
 	InferenceMs float64 // engine/provider time (repeated from the transcription line)
 	ClipSaveMs  float64 // pbpaste fork, concurrent with inference — informational
 	ClipWaitMs  float64 // block on the pbpaste fork after inference returned
+	PolishMs    float64 // LLM polish pass, a fallback to the raw text included
+	RewriteMs   float64 // command mode's model call
 	PasteCopyMs float64 // pbcopy fork inside PasteText
 	PasteKeyMs  float64 // Cmd+V keystroke synthesis inside PasteText
 }
 
 // ReleaseToText records the one latency the user actually feels: hotkey release
 // (or silence auto-close) → text delivered to the clipboard/paste. It spans the
-// whole tail — mic tail-wait, device stop, encode, inference, network, paste — so
-// it is the number to watch for "why did that feel slow", and it is emitted for
-// batch and streaming providers alike, unlike the per-mode metrics lines.
+// whole tail — mic tail-wait, device stop, encode, inference, network, polish,
+// paste — so it is the number to watch for "why did that feel slow", and it is
+// emitted for batch and streaming providers alike, unlike the per-mode metrics
+// lines.
 // unaccounted_ms is the window minus every measured serial stage; a large value
 // means something unmeasured (scheduling, updatesDone) is eating time.
 func ReleaseToText(ms float64, b LatencyBreakdown) {
@@ -260,7 +268,7 @@ func ReleaseToText(ms float64, b LatencyBreakdown) {
 		return
 	}
 	serial := b.TailWaitMs + b.MicStopMs + b.ConvertMs + b.InferenceMs +
-		b.ClipWaitMs + b.PasteCopyMs + b.PasteKeyMs
+		b.ClipWaitMs + b.PolishMs + b.RewriteMs + b.PasteCopyMs + b.PasteKeyMs
 	ev := diagLog.Info().Float64("release_to_text_ms", ms)
 	for _, f := range []struct {
 		key string
@@ -272,6 +280,8 @@ func ReleaseToText(ms float64, b LatencyBreakdown) {
 		{"inference_ms", b.InferenceMs},
 		{"clip_save_ms", b.ClipSaveMs},
 		{"clip_wait_ms", b.ClipWaitMs},
+		{"polish_ms", b.PolishMs},
+		{"rewrite_ms", b.RewriteMs},
 		{"paste_copy_ms", b.PasteCopyMs},
 		{"paste_key_ms", b.PasteKeyMs},
 	} {
@@ -292,6 +302,19 @@ func TranscriptionText(text string) {
 	transcribeFile.WriteString(line)
 }
 
+// TranscriptionRewrite records what post-processing (spoken commands,
+// replacements.txt, polish) made of the transcript just logged by
+// TranscriptionText, on the line after it.
+func TranscriptionRewrite(text string) {
+	if !logReady.Load() || transcribeFile == nil {
+		return
+	}
+	logMu.Lock()
+	defer logMu.Unlock()
+	line := fmt.Sprintf("%s\t[%d]\t=> %s\n", time.Now().Format("2006-01-02 15:04:05"), pid, text)
+	transcribeFile.WriteString(line)
+}
+
 func Confidence(confidence float64) {
 	if !logReady.Load() {
 		return
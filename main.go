@@ -1,19 +1,24 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime/debug"
 	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"zee/alert"
@@ -21,11 +26,13 @@ import (
 	"zee/clipboard"
 	"zee/config"
 	"zee/encoder"
+	"zee/history"
 	"zee/hotkey"
 	"zee/log"
 	"zee/login"
 	"zee/overlay"
 	"zee/permissions"
+	"zee/postprocess"
 	"zee/setup"
 	"zee/shutdown"
 	"zee/transcriber"
@@ -57,6 +64,7 @@ type savedRecording struct {
 	Model       string
 	Timestamp   time.Time
 	Err         string
+	HistoryID   int // its history entry, linked to the audio once saved; 0 for none
 }
 
 var (
@@ -97,6 +105,7 @@ type recSession struct {
 	Stop         <-chan struct{}
 	SilenceClose *atomic.Bool
 	PressedAt    time.Time // when the press was accepted; drives the reflex-latency metric
+	Command      bool      // command mode: the recording is an instruction for the selection
 }
 
 type recordingConfig struct {
@@ -106,10 +115,15 @@ type recordingConfig struct {
 	lang            string
 	hints           string
 	autoPaste       bool
-	tailWait        time.Duration // mic kept open after release so a fast keyup doesn't clip the last word
-	pressToRecordMs float64       // press→mic-live, filled at record start; logged with the transcription metrics
-	releasedAt      time.Time     // recording end, filled once it happens; start of the felt-latency metric
-	micStopMs       float64       // capture stop duration, filled after the record loop ends
+	tailWait        time.Duration         // mic kept open after release so a fast keyup doesn't clip the last word
+	pressToRecordMs float64               // press→mic-live, filled at record start; logged with the transcription metrics
+	releasedAt      time.Time             // recording end, filled once it happens; start of the felt-latency metric
+	micStopMs       float64               // capture stop duration, filled after the record loop ends
+	fallback        []string              // config.json "fallback" chain, tried in order when the session fails
+	post            postprocess.Pipeline  // spoken commands and replacements.txt as of the press
+	polish          *postprocess.Polisher // LLM cleanup pass, nil unless opted in (batch only)
+	command         *commandMode          // command mode's rewrite of the selection; nil when dictating
+	historyID       int                   // the transcript's history entry, once stored
 }
 
 // clipSave carries the saved clipboard content plus how long the pbpaste fork
@@ -149,6 +163,7 @@ func resetStop() <-chan struct{} {
 	stopMu.Lock()
 	stopCh = make(chan struct{})
 	stopOnce = sync.Once{}
+	stopCancelled.Store(false)
 	ch := stopCh
 	stopMu.Unlock()
 	return ch
@@ -176,6 +191,12 @@ func gracefulShutdown() {
 		if n > 0 {
 			log.SessionEnd(n)
 		}
+		if controlSrv != nil {
+			controlSrv.Close()
+		}
+		if appLock != nil {
+			appLock.Release()
+		}
 		log.Close()
 		tray.Quit()
 		os.Exit(0)
@@ -222,6 +243,11 @@ func run() {
 	// Bare subcommands, parsed before the flag set (like git/go verbs). The
 	// -setup flag below stays as an alias so install.sh and older docs keep
 	// working.
+	// `transcribe`, `watch` and `serve` share the flag set (provider, model,
+	// language, output), so they are only noted here and dispatched once a
+	// provider is loaded. The control verbs (start, status, …) are forwarded to
+	// the running app below.
+	verb := ""
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "setup":
@@ -230,6 +256,20 @@ func run() {
 			os.Exit(setup.Doctor())
 		case "update":
 			os.Exit(runUpdate())
+		case "history":
+			if err := config.Load(); err != nil {
+				fmt.Fprintf(os.Stderr, "settings: %v\n", err)
+			}
+			os.Exit(runHistory(os.Args[2:], os.Stdout, os.Stderr))
+		case "samples":
+			if err := config.Load(); err != nil {
+				fmt.Fprintf(os.Stderr, "settings: %v\n", err)
+			}
+			setup.WireProviders()
+			os.Exit(runSamples(os.Args[2:], samplesEnv{resolve: providerByName, copy: clipboard.Copy}, os.Stdout, os.Stderr))
+		case "transcribe", "watch", "serve", "start", "stop", "toggle", "cancel", "status", "last", "switch-model":
+			verb = os.Args[1]
+			os.Args = append(os.Args[:1:1], os.Args[2:]...)
 		}
 	}
 
@@ -246,6 +286,13 @@ func run() {
 	testFlag := flag.Bool("test", false, "Test mode (headless, stdin-driven)")
 	hintsFlag := flag.String("hints", "", "Vocabulary hints for transcription (comma-separated)")
 	transcribeFlag := flag.String("transcribe", "", "Transcribe audio file(s) and exit; extra files may follow as positional args (one transcript printed per line)")
+	outputFormatFlag := flag.String("output-format", "text", "Transcript format for -transcribe: text, json, srt, vtt, or tsv; anything but text is written as a sidecar file")
+	outDirFlag := flag.String("outdir", "", "Directory for -transcribe sidecar files (default: next to each input)")
+	rawRateFlag := flag.Int("raw-rate", 0, "zee transcribe -: stdin is raw s16le mono PCM at this rate (Hz) instead of WAV")
+	listenFlag := flag.String("listen", serveDefaultAddr, "zee serve: address to listen on")
+	tokenFlag := flag.String("token", "", "zee serve: require this bearer token (default: $ZEE_SERVE_TOKEN)")
+	jobsFlag := flag.Int("jobs", 4, "zee transcribe: files in flight at once (cloud providers; local engines take one at a time)")
+	manifestFlag := flag.String("manifest", "", "zee transcribe/watch: JSONL manifest of finished files, for resuming (default: "+batchManifestName+" in -outdir or the current directory)")
 	providerFlag := flag.String("provider", "", "Transcription provider (e.g. parakeet, groq); overrides saved config")
 	modelFlag := flag.String("model", "", "Model ID for the selected provider; overrides saved config")
 	flag.Parse()
@@ -286,10 +333,42 @@ func run() {
 		os.Exit(0)
 	}
 
-	// Cloud provider API keys come from credentials.json (via config.APIKey),
-	// never the environment. Wire the resolver before any provider is used —
-	// including the setup wizard below, which resolves a transcriber.
-	transcriber.SetKeySource(config.APIKey)
+	// One app at a time (instance.go): a second launch, or a control verb,
+	// hands its intent to the running app and exits before touching the
+	// hotkey, the mic or a model. With none running, `start` and `toggle`
+	// become the app and begin recording at once.
+	startOnLaunch := false
+	if (verb == "" || controlVerbs[verb]) && !*setupFlag && !*testFlag && *benchmarkFile == "" && *transcribeFlag == "" {
+		cmd := verb
+		if verb == "switch-model" {
+			cmd += " " + flag.Arg(0)
+		}
+		lock, owner, err := acquireInstance(instanceLockPath())
+		switch {
+		case errors.Is(err, errInstanceRunning):
+			if verb == "" {
+				alert.Info("Zee is already running.")
+			}
+			os.Exit(forwardToInstance(controlSocketPath(), owner, cmd, os.Stdout))
+		case err != nil:
+			log.Warnf("instance lock: %v", err) // run unguarded rather than not at all
+		}
+		appLock = lock
+		switch verb {
+		case "", "start", "toggle":
+			startOnLaunch = verb != ""
+		default:
+			if appLock != nil {
+				appLock.Release()
+			}
+			fmt.Fprintln(os.Stderr, "zee is not running")
+			os.Exit(1)
+		}
+	}
+
+	// Wire the resolvers before any provider is used — including the setup
+	// wizard below, which resolves a transcriber.
+	setup.WireProviders()
 
 	// The setup wizard is a self-contained mode: it configures provider/key,
 	// device, permissions and hotkey, then launches the app and exits. It does
@@ -327,6 +406,9 @@ func run() {
 	default:
 		fatal("Unknown format %q (use mp3@16, mp3@64, or flac)", *formatFlag)
 	}
+	if _, ok := outputFormats[*outputFormatFlag]; !ok {
+		fatal("Unknown output format %q (use text, json, srt, vtt, or tsv)", *outputFormatFlag)
+	}
 
 	// CLI -provider/-model override the saved provider/model (also lets the
 	// integration test pick a specific local model).
@@ -376,6 +458,53 @@ func run() {
 		log.SessionStart(activeTranscriber.Name(), activeFormat, activeFormat)
 	}
 
+	switch verb {
+	case "transcribe":
+		if flag.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Usage: zee transcribe [flags] <file|dir|glob>... | -")
+			os.Exit(2)
+		}
+		if slices.Contains(flag.Args(), "-") {
+			if flag.NArg() > 1 {
+				fatal("zee transcribe: - (stdin) can't be mixed with other inputs")
+			}
+			os.Exit(runStdin(os.Stdin, os.Stdout, os.Stderr, stdinOptions{
+				rawRate: *rawRateFlag,
+				format:  *outputFormatFlag,
+				stream:  streamEnabled,
+			}))
+		}
+		p, _ := providerByName(activeTranscriber.Name())
+		os.Exit(runBatch(flag.Args(), batchOptions{
+			format:   *outputFormatFlag,
+			outDir:   *outDirFlag,
+			jobs:     *jobsFlag,
+			manifest: *manifestFlag,
+			local:    p.Local,
+		}, os.Stdout, os.Stderr))
+	case "watch":
+		if flag.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: zee watch [flags] <dir>")
+			os.Exit(2)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := runWatch(ctx, flag.Arg(0), watchOptions{
+			format:   *outputFormatFlag,
+			outDir:   *outDirFlag,
+			manifest: *manifestFlag,
+		}, os.Stdout, os.Stderr)
+		stop()
+		os.Exit(code)
+	case "serve":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := runServe(ctx, serveOptions{
+			addr:  *listenFlag,
+			token: cmp.Or(*tokenFlag, os.Getenv("ZEE_SERVE_TOKEN")),
+		})
+		stop()
+		os.Exit(code)
+	}
+
 	if *testFlag {
 		args := flag.Args()
 		if len(args) == 0 {
@@ -394,7 +523,7 @@ func run() {
 	if *transcribeFlag != "" {
 		// First file is the flag value; any remaining positionals are extra
 		// files transcribed in the same process (the model loads once).
-		runTranscribeFiles(append([]string{*transcribeFlag}, flag.Args()...))
+		runTranscribeFiles(append([]string{*transcribeFlag}, flag.Args()...), *outputFormatFlag, *outDirFlag)
 		return
 	}
 
@@ -437,6 +566,7 @@ func run() {
 	defer captureDevice.Close()
 
 	tray.OnCopyLast(clip.CopyLast)
+	refreshRecent()
 	tray.OnRecord(
 		func() {
 			select {
@@ -768,6 +898,37 @@ func run() {
 
 	sessions := make(chan recSession, 1)
 	go listenHotkey(hk, hotkey.LongPress(), sessions)
+	if chk := registerCommandHotkey(hk.Current(), sessions); chk != nil {
+		defer chk.Unregister()
+	}
+
+	// The control socket's switch-model: switchModel's guard, answered instead
+	// of beeped, and only to a model that is already on disk.
+	ctlSwitch := func(provider, model string) (string, string, error) {
+		p, ok := providerByName(provider)
+		if !ok {
+			return "", "", fmt.Errorf("unknown provider %q", provider)
+		}
+		if model == "" && len(p.Models) > 0 {
+			model = p.Models[0].ID
+		}
+		if _, ok := modelIndex[p.Name+":"+model]; !ok {
+			return "", "", fmt.Errorf("unknown model %q for %s", model, p.Name)
+		}
+		if st := p.Status(model); !st.Ready {
+			return "", "", fmt.Errorf("%s/%s is not ready: %s", p.Name, model, st.Detail)
+		}
+		if isRecording.Load() {
+			return "", "", errControlBusy
+		}
+		applySwitch(p, model)
+		return p.Name, model, nil
+	}
+	if srv, err := listenControl(controlSocketPath(), sessions, ctlSwitch); err != nil {
+		log.Warnf("control socket: %v", err)
+	} else {
+		controlSrv = srv
+	}
 
 	// Apply a re-read config.json (tray "Reload Config") field by field, each
 	// through the same path its tray callback uses. Reload is user-initiated
@@ -902,6 +1063,10 @@ func run() {
 		}
 	}()
 
+	if startOnLaunch {
+		tryStartSession(sessions)
+	}
+
 	recordSessions(func() audio.CaptureDevice {
 		captureMu.Lock()
 		defer captureMu.Unlock()
@@ -954,18 +1119,37 @@ func denyBusy(warning string) {
 // The hotkey and the tray "Start Recording" button both funnel through here, so
 // neither can queue an unattended recording that fires the instant inference ends.
 func tryStartSession(sessions chan<- recSession) *atomic.Bool {
+	return tryStartCycle(sessions, false)
+}
+
+// tryStartCycle is tryStartSession for either hotkey: command starts a
+// command-mode session.
+func tryStartCycle(sessions chan<- recSession, command bool) *atomic.Bool {
+	sc := claimCycle(sessions, command)
+	if sc == nil {
+		denyBusy("Already recording or transcribing.")
+	}
+	return sc
+}
+
+// claimSession is tryStartSession without the denial: nil when busy. The
+// control socket starts through here, answering "busy" itself.
+func claimSession(sessions chan<- recSession) *atomic.Bool {
+	return claimCycle(sessions, false)
+}
+
+func claimCycle(sessions chan<- recSession, command bool) *atomic.Bool {
 	// Claiming the cycle IS the guard: a plain check-then-send is not atomic
 	// (isRecording only went true once recordSessions picked the session up), so
 	// a hotkey press and a tray click landing together could both pass and both
 	// enqueue — the second firing unattended the moment the first cycle ended.
 	// recordSessions clears the flag at cycle end exactly as before.
 	if !isRecording.CompareAndSwap(false, true) {
-		denyBusy("Already recording or transcribing.")
 		return nil
 	}
 	sc := &atomic.Bool{}
 	audio.PlayStart() // reflexive: sound the press now, not after the record loop spins up (playOne is non-blocking)
-	sessions <- recSession{Stop: resetStop(), SilenceClose: sc, PressedAt: time.Now()}
+	sessions <- recSession{Stop: resetStop(), SilenceClose: sc, PressedAt: time.Now(), Command: command}
 	return sc
 }
 
@@ -986,6 +1170,7 @@ func recordSessions(getCapture func() audio.CaptureDevice, sessions <-chan recSe
 		isRecording.Store(true) // already set when the session came from tryStartSession
 		tray.SetRecording(true)
 		overlay.Show() // every path — hotkey, toggle, tray — funnels through here
+		emitEvent("recording_started", nil)
 
 		done, err := handleRecording(capture, sess)
 		if err != nil {
@@ -995,12 +1180,14 @@ func recordSessions(getCapture func() audio.CaptureDevice, sessions <-chan recSe
 		if done != nil {
 			isTranscribing.Store(true)
 			overlay.SetState(overlay.Transcribing)
+			emitEvent("transcribing", nil)
 			<-done // hold isRecording too — blocks re-record
 			isTranscribing.Store(false)
 		}
 		isRecording.Store(false)
 		tray.SetRecording(false)
 		overlay.Hide() // one exit for the whole cycle: record, then inference
+		emitEvent("idle", nil)
 		if afterRecordCycle != nil {
 			afterRecordCycle()
 		}
@@ -1008,6 +1195,12 @@ func recordSessions(getCapture func() audio.CaptureDevice, sessions <-chan recSe
 }
 
 func listenHotkey(hk hotkey.Hotkey, longPress time.Duration, sessions chan<- recSession) {
+	listenPresses(hk, longPress, sessions, false)
+}
+
+// listenPresses turns hk's presses into sessions — command-mode ones when
+// command is set.
+func listenPresses(hk hotkey.Hotkey, longPress time.Duration, sessions chan<- recSession, command bool) {
 	for {
 		<-hk.Keydown()
 		if isRecording.Load() {
@@ -1020,7 +1213,7 @@ func listenHotkey(hk hotkey.Hotkey, longPress time.Duration, sessions chan<- rec
 			}
 			continue
 		}
-		sc := tryStartSession(sessions)
+		sc := tryStartCycle(sessions, command)
 		if sc == nil {
 			log.HotkeyPress(0, "denied")
 			<-hk.Keyup() // denied (a cycle began between the guard above and here)
@@ -1033,6 +1226,9 @@ func listenHotkey(hk hotkey.Hotkey, longPress time.Duration, sessions chan<- rec
 		if toggled {
 			mode = "toggle"
 		}
+		if command {
+			mode = "command_" + mode
+		}
 		log.HotkeyPress(float64(downToUp.Milliseconds()), mode)
 		requestStop()
 	}
@@ -1142,22 +1338,59 @@ func handleRecording(capture audio.CaptureDevice, sess recSession) (<-chan struc
 		hints:     config.GetHints(),
 		autoPaste: autoPaste,
 		tailWait:  time.Duration(config.Get().TailWaitMs) * time.Millisecond,
+		fallback:  config.Get().Fallback,
 	}
 	configMu.Unlock()
+	cfg.post = currentPipeline(cfg.lang)
+	cfg.polish = currentPolisher()
+	if sess.Command {
+		// The transcript is an instruction: batch, free of spoken commands and
+		// polish, and the paste back over the selection is the point — the
+		// auto-paste toggle doesn't apply, only the permission.
+		cfg.command = &commandMode{rewriter: currentRewriter(), selection: make(chan string, 1)}
+		cfg.stream = false
+		cfg.post.Commands = nil
+		cfg.polish = nil
+		cfg.autoPaste = true
+	}
 	if cfg.autoPaste && !permissions.HasAccessibility() {
+		if cfg.command != nil {
+			// The selection is copied with a keystroke that would be dropped,
+			// after the clipboard was cleared for it, with no paste to restore
+			// it behind: refuse before anything is recorded.
+			return nil, errCommandAccessibility
+		}
 		cfg.autoPaste = false
 		tray.SetError("Auto-paste is waiting for Accessibility permission")
 	}
 
-	tSess, err := cfg.tr.NewSession(context.Background(), transcriber.SessionConfig{
+	sessCfg := transcriber.SessionConfig{
 		Stream:   cfg.stream,
 		Format:   cfg.format,
 		Language: cfg.lang,
 		Hints:    cfg.hints,
-	})
+	}
+	tSess, err := cfg.tr.NewSession(context.Background(), sessCfg)
 	if err != nil {
 		return nil, err
 	}
+	// A long batch dictation goes up a window at a time (see chunk.go);
+	// streaming sessions have no upload to outgrow.
+	if !cfg.stream {
+		if vp, err := newVADProcessor(); err == nil {
+			p, _ := providerByName(cfg.tr.Name())
+			cs := newChunkedSession(tSess, func() (transcriber.Session, error) {
+				return cfg.tr.NewSession(context.Background(), sessCfg)
+			}, vp.IsSpeech, p.Local)
+			if len(cfg.fallback) > 0 {
+				tr := cfg.tr
+				cs.fallback = func(data []byte, format string) (*fallbackHit, error) {
+					return transcribeFallback(cfg.fallback, providerByName, tr, data, format, cfg.lang, cfg.hints)
+				}
+			}
+			tSess = cs
+		}
+	}
 
 	// Save the clipboard before the first overwrite so it can be restored
 	// after the paste — but never during the press: atotto's Read forks
@@ -1178,13 +1411,33 @@ func handleRecording(capture audio.CaptureDevice, sess recSession) (<-chan struc
 	updatesDone := make(chan struct{})
 	go func() {
 		defer close(updatesDone)
-		var prev string
-		for text := range tSess.Updates() {
-			if cfg.autoPaste && len(text) > len(prev) {
+		// Streamed text is pasted through post-processing as it grows, minus
+		// what a later word could still rewrite (Stable); the held-back tail
+		// goes out once the stream has ended.
+		var pasted, last string
+		paste := func(text string) {
+			if cfg.autoPaste && len(text) > len(pasted) && !stopCancelled.Load() {
 				saveClip()
-				clip.PasteText(text[len(prev):])
+				clip.PasteText(text[len(pasted):])
+				pasted = text
 			}
-			prev = text
+		}
+		for text := range tSess.Updates() {
+			paste(cfg.post.Stable(text))
+			last = text
+		}
+		if last == "" {
+			return
+		}
+		final := cfg.post.Apply(last)
+		if !strings.HasPrefix(final.Text, pasted) {
+			// "scratch that" reaching back past text already pasted.
+			log.Warnf("stream: post-processing changed text already pasted")
+			return
+		}
+		paste(final.Text)
+		if final.Enter && cfg.autoPaste && !stopCancelled.Load() {
+			clip.PressEnter()
 		}
 	}()
 
@@ -1212,7 +1465,23 @@ func handleRecording(capture audio.CaptureDevice, sess recSession) (<-chan struc
 		tSess.Close()
 		return nil, nil
 	}
-	if cfg.autoPaste {
+	// Cancelled from the control socket: the cycle ends here. Session has no
+	// abort, so it is still closed (a stream must hang up) but in the
+	// background, its result discarded; a stream may already have pasted what
+	// it heard before the cancel.
+	if stopCancelled.Load() {
+		go tSess.Close()
+		log.Info("recording_cancelled")
+		emitEvent("cancelled", nil)
+		return nil, nil
+	}
+	if cfg.command != nil {
+		// The selection is copied over the clipboard, so it is saved first.
+		go func() {
+			saveClip()
+			cfg.command.selection <- clip.CopySelection()
+		}()
+	} else if cfg.autoPaste {
 		go saveClip() // keys are up now; the pbpaste fork can't distort the press
 	}
 
@@ -1228,6 +1497,10 @@ func handleRecording(capture audio.CaptureDevice, sess recSession) (<-chan struc
 func finishTranscription(sess transcriber.Session, clipCh chan clipSave, updatesDone <-chan struct{}, skipPaste bool, recDur time.Duration, cfg recordingConfig) {
 	result, closeErr := sess.Close()
 	<-updatesDone
+	// Cancelled mid-transcription: finish the cycle's bookkeeping but deliver
+	// nothing.
+	cancelled := stopCancelled.Load()
+	skipPaste = skipPaste || cancelled
 
 	var clipPrev string
 	var lat log.LatencyBreakdown
@@ -1239,9 +1512,32 @@ func finishTranscription(sess transcriber.Session, clipCh chan clipSave, updates
 		lat.ClipWaitMs = float64(time.Since(t).Microseconds()) / 1000
 	}
 
+	// A failed request needn't lose the dictation: hand the same audio to the
+	// configured fallback chain. Not when text already streamed out — a second
+	// transcript would paste it twice — nor for a chunked dictation, whose
+	// failed windows already went through the chain one by one.
+	var via string
+	if closeErr != nil && len(cfg.fallback) > 0 && len(result.AudioData) > 0 && result.Text == "" &&
+		!errors.Is(closeErr, errWindowsFailed) {
+		log.Warnf("transcription error: %v — trying fallback", closeErr)
+		hit, err := transcribeFallback(cfg.fallback, providerByName, cfg.tr, result.AudioData, result.AudioFormat, cfg.lang, cfg.hints)
+		if err == nil {
+			result = fallbackSessionResult(result, hit, recDur)
+			// From here on the log, tray and saved recording name the provider
+			// that produced the text. Nothing streamed, so the batch paste runs.
+			cfg.tr = hit.tr
+			cfg.stream = false
+			via = hit.tr.Name()
+			closeErr = nil
+		} else {
+			closeErr = fmt.Errorf("%w (fallback: %v)", closeErr, err)
+		}
+	}
+
 	if closeErr != nil {
 		log.Errorf("transcription error: %v", closeErr)
 		tray.SetError(closeErr.Error())
+		emitEvent("error", map[string]any{"error": closeErr.Error()})
 		// Auto-save the failed recording so it can be recovered/retried, and
 		// tell the user what actually happened — an error alert, not the
 		// manual save's "Saved to" notice.
@@ -1264,29 +1560,64 @@ func finishTranscription(sess transcriber.Session, clipCh chan clipSave, updates
 		}
 	}
 
+	// Post-processing rewrites what is delivered; the saved recording keeps
+	// the raw transcript.
+	out := cfg.post.Apply(result.Text)
+	delivered := true // false when there is no text to show for the cycle
+	if cfg.command != nil && closeErr == nil && result.HasText && !cancelled {
+		text, ms, err := rewriteSelection(cfg.command, out.Text)
+		lat.RewriteMs = ms
+		if err != nil {
+			// Never paste the instruction over the selection.
+			log.Warnf("command mode: %v", err)
+			tray.SetError("Command mode: " + err.Error())
+			emitEvent("error", map[string]any{"error": "command mode: " + err.Error()})
+			skipPaste, delivered = true, false
+		} else {
+			out.Text = text
+		}
+		cancelled = stopCancelled.Load()
+		skipPaste = skipPaste || cancelled
+	} else if cfg.polish != nil && closeErr == nil && !cfg.stream && result.HasText && !cancelled {
+		out.Text, lat.PolishMs = polishText(cfg.polish, out.Text)
+		// A cancel can land while the model is still answering.
+		cancelled = stopCancelled.Load()
+		skipPaste = skipPaste || cancelled
+	}
+
 	if closeErr == nil && !cfg.stream && result.HasText && cfg.autoPaste && !skipPaste {
-		lat.PasteCopyMs, lat.PasteKeyMs = clip.PasteText(result.Text)
+		lat.PasteCopyMs, lat.PasteKeyMs = clip.PasteText(out.Text)
+		if out.Enter {
+			clip.PressEnter()
+		}
 	}
 
 	// The text is delivered by here on both paths — streamed pastes were joined
 	// at updatesDone above, the batch paste just happened — so this is the end of
 	// the wait the user perceives, whether it ended in a paste or in text they
 	// still have to hit Cmd+V for.
+	var feltMs float64
 	if closeErr == nil && result.HasText && !cfg.releasedAt.IsZero() {
+		feltMs = float64(time.Since(cfg.releasedAt).Microseconds()) / 1000
 		lat.TailWaitMs = float64(cfg.tailWait.Milliseconds())
 		lat.MicStopMs = cfg.micStopMs
 		if result.Batch != nil {
 			lat.ConvertMs = result.Batch.ConvertMs
 			lat.InferenceMs = result.Batch.InferenceMs
 		}
-		log.ReleaseToText(float64(time.Since(cfg.releasedAt).Microseconds())/1000, lat)
+		log.ReleaseToText(feltMs, lat)
 	}
 
-	if cfg.autoPaste && !skipPaste {
+	// Command mode copied the selection over the clipboard whether or not
+	// anything was pasted (it only runs with auto-paste on).
+	if cfg.autoPaste && (!skipPaste || cfg.command != nil) {
 		clip.ScheduleRestore(clipPrev)
 	}
 
 	if closeErr != nil {
+		if !cancelled {
+			archiveRecording(result, cfg, archiveInfo{Error: closeErr.Error(), DurationS: recDur.Seconds()})
+		}
 		return
 	}
 
@@ -1313,6 +1644,8 @@ func finishTranscription(sess transcriber.Session, clipCh chan clipSave, updates
 			ProcessRSSMB:     result.ProcessRSSMB,
 			InferenceMs:      bs.InferenceMs,
 			PressToRecordMs:  cfg.pressToRecordMs,
+			Retries:          bs.Retries,
+			RetryMs:          bs.RetryMs,
 		}
 		transcriptionsMu.Lock()
 		transcriptionCount++
@@ -1338,18 +1671,40 @@ func finishTranscription(sess transcriber.Session, clipCh chan clipSave, updates
 		})
 	}
 
-	if !result.NoSpeech {
-		clip.SetLastText(result.Text)
-		log.TranscriptionText(result.Text)
+	if cancelled {
+		log.Info("transcription_cancelled")
+		emitEvent("cancelled", nil)
+	} else if !result.NoSpeech {
+		emitEvent("text", map[string]any{"text": out.Text, "provider": cfg.tr.Name(), "model": cfg.tr.GetModel()})
+		clip.SetLastText(out.Text)
+		logTranscript(result.Text, out)
 		var totalMs float64
 		if result.Batch != nil {
 			totalMs = result.Batch.TotalTimeMs
 		} else if result.Stream != nil {
 			totalMs = result.Stream.TotalMs
 		}
-		tray.SetLastRecording(recDur, totalMs)
+		tray.SetLastRecording(recDur, totalMs, via)
+		if delivered && strings.TrimSpace(out.Text) != "" {
+			cfg.historyID = addHistory(history.Entry{
+				Provider:  cfg.tr.Name(),
+				Model:     cfg.tr.GetModel(),
+				Language:  cfg.lang,
+				DurationS: recDur.Seconds(),
+				LatencyMs: cmp.Or(feltMs, totalMs),
+				Text:      out.Text,
+			})
+		}
 	}
 
+	if !cancelled {
+		archiveRecording(result, cfg, archiveInfo{
+			Delivered: out.Text,
+			DurationS: recDur.Seconds(),
+			FeltMs:    feltMs,
+			Latency:   lat,
+		})
+	}
 	setLastRecording(result, cfg, "")
 }
 
@@ -1369,6 +1724,7 @@ func setLastRecording(result transcriber.SessionResult, cfg recordingConfig, err
 		Model:       cfg.tr.GetModel(),
 		Timestamp:   time.Now(),
 		Err:         errStr,
+		HistoryID:   cfg.historyID,
 	}
 	lastRecMu.Unlock()
 }
@@ -1416,6 +1772,7 @@ func persistLastRecording() (string, error) {
 		"timestamp": rec.Timestamp.Format(time.RFC3339),
 	})
 	os.WriteFile(filepath.Join(dir, "info.json"), info, 0644)
+	linkHistoryAudio(rec.HistoryID, dir)
 
 	return dir, nil
 }
@@ -1464,7 +1821,7 @@ func pruneFailedSamples() {
 }
 
 // directTranscriber transcribes encoded audio bytes in one call. Every provider
-// implements it — cloud providers POST the bytes; Parakeet decodes WAV → PCM and
+// implements it — cloud providers POST the bytes; local engines decode the file to PCM and
 // runs a local batch inference — so the file path has a single shape.
 type directTranscriber interface {
 	Transcribe(audio []byte, format, lang, hints string) (*transcriber.Result, error)
@@ -1482,21 +1839,32 @@ func providerByName(name string) (transcriber.ProviderInfo, bool) {
 
 // runTranscribeFiles transcribes one or more files with the already-loaded
 // engine — the model is loaded once at startup and reused across files — and
-// prints one transcript per line, in input order.
-func runTranscribeFiles(files []string) {
+// prints one transcript per line, in input order. With a non-text format or an
+// outdir, each transcript goes to a sidecar file instead and the line printed
+// is its path.
+func runTranscribeFiles(files []string, format, outDir string) {
+	sidecar := format != "text" || outDir != ""
 	for _, f := range files {
-		text, err := transcribeFile(f)
+		result, err := transcribeFile(f)
+		if err != nil {
+			fatal("%s: %v", f, err)
+		}
+		if !sidecar {
+			fmt.Println(result.Text)
+			continue
+		}
+		path, err := writeSidecar(f, format, outDir, result)
 		if err != nil {
 			fatal("%s: %v", f, err)
 		}
-		fmt.Println(text)
+		fmt.Println(path)
 	}
 }
 
-func transcribeFile(audioFile string) (string, error) {
+func transcribeFile(audioFile string) (*transcriber.Result, error) {
 	data, err := os.ReadFile(audioFile)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	ext := filepath.Ext(audioFile)
@@ -1509,18 +1877,42 @@ func transcribeFile(audioFile string) (string, error) {
 	case ".mp3":
 		format = "mp3"
 	default:
-		return "", fmt.Errorf("unsupported audio format %q", ext)
+		return nil, fmt.Errorf("unsupported audio format %q", ext)
 	}
+	return transcribeAudio(data, format, activeTranscriber.GetLanguage(), config.GetHints())
+}
 
+// transcribeAudio transcribes encoded audio ("wav", "mp3" or "flac") with the
+// active provider in one request, or window by window when it is long.
+func transcribeAudio(data []byte, format, lang, hints string) (*transcriber.Result, error) {
 	dt, ok := activeTranscriber.(directTranscriber)
 	if !ok {
-		return "", fmt.Errorf("provider %q cannot transcribe files", activeTranscriber.Name())
+		return nil, fmt.Errorf("provider %q cannot transcribe files", activeTranscriber.Name())
+	}
+	// Only the chunked path needs PCM; the length comes from the headers. A
+	// file the decoders can't read still goes up whole, as before.
+	dur, durErr := audio.Duration(data, format)
+	if durErr == nil && needsChunking(len(data), dur) {
+		pcm, err := audio.DecodeToPCM(data, format)
+		if err != nil {
+			return nil, err
+		}
+		local := false
+		if p, ok := providerByName(activeTranscriber.Name()); ok {
+			local = p.Local
+		}
+		return transcribeChunked(dt, pcm, local, lang, hints)
 	}
-	result, err := dt.Transcribe(data, format, activeTranscriber.GetLanguage(), config.GetHints())
+	result, err := dt.Transcribe(data, format, lang, hints)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	// Not every provider reports the duration; the subtitle formats need it
+	// when there are no segments.
+	if result.Duration == 0 && durErr == nil {
+		result.Duration = dur.Seconds()
 	}
-	return result.Text, nil
+	return result, nil
 }
 
 func runBenchmark(wavFile string, runs int) {
@@ -1543,12 +1935,12 @@ func runBenchmark(wavFile string, runs int) {
 			fmt.Printf("Error reading file: %v\n", err)
 			return
 		}
-		if len(data) < audio.WAVHeaderSize {
-			fmt.Println("Error: invalid WAV file")
+		audioData, err := audio.WAVToPCM(data)
+		if err != nil {
+			fmt.Printf("Error: invalid WAV file: %v\n", err)
 			return
 		}
 
-		audioData := data[audio.WAVHeaderSize:]
 		audioDuration := float64(len(audioData)/2) / float64(encoder.SampleRate)
 		fmt.Printf("Simulating %.1fs recording...\n", audioDuration)
 
@@ -1,17 +1,33 @@
 package main
 
 import (
+	"os"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"zee/audio"
+	"zee/config"
 	"zee/encoder"
 	"zee/hotkey"
 	"zee/transcriber"
 )
 
+// TestMain points the config dir at a scratch directory: a finished test
+// dictation writes history and replacements.txt and reads hints like a real
+// one, and must not touch the user's.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "zee-test-config")
+	if err != nil {
+		panic(err)
+	}
+	config.SetDir(dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 // TestRecordSessionsBlocksDuringInference verifies the guard's missing half:
 // isRecording must stay true for the WHOLE record+transcribe cycle, not just
 // while recording. It drives the real recordSessions loop with a fake capture
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"zee/transcriber"
+)
+
+// outputFormats maps each -output-format to its sidecar file extension.
+var outputFormats = map[string]string{
+	"text": "txt",
+	"json": "json",
+	"srt":  "srt",
+	"vtt":  "vtt",
+	"tsv":  "tsv",
+}
+
+// transcriptSegments is the timeline the subtitle formats are cut from. Only
+// some providers (Groq, OpenAI-compatible servers) return segments; for the
+// rest the whole transcript becomes one cue spanning the audio.
+func transcriptSegments(res *transcriber.Result) ([]transcriber.Segment, error) {
+	if len(res.Segments) > 0 {
+		return res.Segments, nil
+	}
+	text := strings.TrimSpace(res.Text)
+	if text == "" {
+		return nil, nil
+	}
+	if res.Duration <= 0 {
+		return nil, fmt.Errorf("provider returned no timestamps or duration")
+	}
+	return []transcriber.Segment{{Text: text, End: res.Duration}}, nil
+}
+
+// formatTranscript renders res in one of the outputFormats. source is the
+// input file, recorded in the JSON output.
+func formatTranscript(format string, res *transcriber.Result, source string) ([]byte, error) {
+	if format == "text" {
+		return []byte(strings.TrimSpace(res.Text) + "\n"), nil
+	}
+	if format == "json" {
+		return formatJSON(res, source)
+	}
+	segs, err := transcriptSegments(res)
+	if err != nil {
+		return nil, fmt.Errorf("%s output: %w", format, err)
+	}
+	var b bytes.Buffer
+	switch format {
+	case "srt":
+		for i, s := range segs {
+			fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1,
+				cueTime(s.Start, ","), cueTime(s.End, ","), strings.TrimSpace(s.Text))
+		}
+	case "vtt":
+		b.WriteString("WEBVTT\n\n")
+		for _, s := range segs {
+			fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+				cueTime(s.Start, "."), cueTime(s.End, "."), strings.TrimSpace(s.Text))
+		}
+	case "tsv":
+		// whisper's layout: integer milliseconds, tabs in the text flattened.
+		b.WriteString("start\tend\ttext\n")
+		for _, s := range segs {
+			text := strings.ReplaceAll(strings.TrimSpace(s.Text), "\t", " ")
+			fmt.Fprintf(&b, "%d\t%d\t%s\n", secToMs(s.Start), secToMs(s.End), text)
+		}
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+	return b.Bytes(), nil
+}
+
+type jsonSegment struct {
+	Start        float64 `json:"start"`
+	End          float64 `json:"end"`
+	Text         string  `json:"text"`
+	AvgLogProb   float64 `json:"avg_logprob,omitempty"`
+	NoSpeechProb float64 `json:"no_speech_prob,omitempty"`
+}
+
+type jsonTranscript struct {
+	File     string        `json:"file,omitempty"`
+	Provider string        `json:"provider,omitempty"`
+	Model    string        `json:"model,omitempty"`
+	Language string        `json:"language,omitempty"`
+	Duration float64       `json:"duration,omitempty"`
+	Text     string        `json:"text"`
+	Segments []jsonSegment `json:"segments,omitempty"`
+}
+
+func formatJSON(res *transcriber.Result, source string) ([]byte, error) {
+	out := jsonTranscript{
+		File:     source,
+		Duration: res.Duration,
+		Text:     strings.TrimSpace(res.Text),
+	}
+	if activeTranscriber != nil {
+		out.Provider = activeTranscriber.Name()
+		out.Model = activeTranscriber.GetModel()
+		out.Language = activeTranscriber.GetLanguage()
+	}
+	for _, s := range res.Segments {
+		out.Segments = append(out.Segments, jsonSegment{
+			Start:        s.Start,
+			End:          s.End,
+			Text:         strings.TrimSpace(s.Text),
+			AvgLogProb:   s.AvgLogProb,
+			NoSpeechProb: s.NoSpeechProb,
+		})
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func secToMs(sec float64) int64 { return int64(sec*1000 + 0.5) }
+
+// cueTime formats seconds as HH:MM:SS<sep>mmm — sep is "," for SRT, "." for VTT.
+func cueTime(sec float64, sep string) string {
+	ms := secToMs(max(sec, 0))
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// sidecarPath is where the transcript of input is written: the input's name
+// with the format's extension, next to the input or inside outDir. When
+// another recording shares the name (talk.wav beside talk.mp3) the input's
+// own extension is kept — talk.mp3.srt — so neither overwrites the other.
+func sidecarPath(input, format, outDir string) string {
+	name := filepath.Base(input)
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	if hasAudioSibling(input) {
+		stem = name
+	}
+	base := stem + "." + outputFormats[format]
+	if outDir != "" {
+		return filepath.Join(outDir, base)
+	}
+	return filepath.Join(filepath.Dir(input), base)
+}
+
+// hasAudioSibling reports whether input's folder holds another recording
+// with the same name and a different audio extension.
+func hasAudioSibling(input string) bool {
+	stem := strings.TrimSuffix(input, filepath.Ext(input))
+	for _, ext := range batchAudioExts {
+		if strings.EqualFold(ext, filepath.Ext(input)) {
+			continue
+		}
+		for _, e := range []string{ext, strings.ToUpper(ext)} {
+			if _, err := os.Stat(stem + e); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeSidecar renders res and writes it beside input (or into outDir),
+// returning the path written.
+func writeSidecar(input, format, outDir string, res *transcriber.Result) (string, error) {
+	data, err := formatTranscript(format, res, input)
+	if err != nil {
+		return "", err
+	}
+	path := sidecarPath(input, format, outDir)
+	if outDir != "" {
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zee/transcriber"
+)
+
+func segmentedResult() *transcriber.Result {
+	return &transcriber.Result{
+		Text:     "Hello there. General Kenobi.",
+		Duration: 3725.5,
+		Segments: []transcriber.Segment{
+			{Text: " Hello there.", Start: 0, End: 1.25},
+			{Text: " General\tKenobi.", Start: 3723.1, End: 3725.5},
+		},
+	}
+}
+
+func TestFormatTranscriptSubtitles(t *testing.T) {
+	cases := []struct {
+		format, want string
+	}{
+		{"srt", "1\n00:00:00,000 --> 00:00:01,250\nHello there.\n\n" +
+			"2\n01:02:03,100 --> 01:02:05,500\nGeneral\tKenobi.\n\n"},
+		{"vtt", "WEBVTT\n\n00:00:00.000 --> 00:00:01.250\nHello there.\n\n" +
+			"01:02:03.100 --> 01:02:05.500\nGeneral\tKenobi.\n\n"},
+		{"tsv", "start\tend\ttext\n0\t1250\tHello there.\n3723100\t3725500\tGeneral Kenobi.\n"},
+		{"text", "Hello there. General Kenobi.\n"},
+	}
+	for _, c := range cases {
+		got, err := formatTranscript(c.format, segmentedResult(), "a.wav")
+		if err != nil {
+			t.Fatalf("%s: %v", c.format, err)
+		}
+		if string(got) != c.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", c.format, got, c.want)
+		}
+	}
+}
+
+func TestFormatTranscriptWithoutSegments(t *testing.T) {
+	// No segments but a known duration: one cue spanning the audio.
+	res := &transcriber.Result{Text: "just text", Duration: 2.5}
+	got, err := formatTranscript("srt", res, "a.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1\n00:00:00,000 --> 00:00:02,500\njust text\n\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Neither: a subtitle can't be timed, but JSON still works.
+	res.Duration = 0
+	if _, err := formatTranscript("vtt", res, "a.wav"); err == nil {
+		t.Error("vtt without timestamps or duration: want error")
+	}
+	got, err = formatTranscript("json", res, "a.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), `"text": "just text"`) || strings.Contains(string(got), "segments") {
+		t.Errorf("json: %s", got)
+	}
+
+	// Silence is an empty subtitle, not an error.
+	got, err = formatTranscript("vtt", &transcriber.Result{}, "a.wav")
+	if err != nil || string(got) != "WEBVTT\n\n" {
+		t.Errorf("empty vtt = %q, %v", got, err)
+	}
+}
+
+func TestWriteSidecar(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.take1.wav")
+
+	path, err := writeSidecar(input, "srt", "", segmentedResult())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "talk.take1.srt"); path != want {
+		t.Errorf("next to input: path = %s, want %s", path, want)
+	}
+
+	outDir := filepath.Join(dir, "out", "nested")
+	path, err = writeSidecar(input, "text", outDir, segmentedResult())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(outDir, "talk.take1.txt"); path != want {
+		t.Errorf("outdir: path = %s, want %s", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "Hello there. General Kenobi.\n" {
+		t.Errorf("outdir contents = %q, %v", data, err)
+	}
+}
+
+func TestSidecarPathKeepsExtensionOnCollision(t *testing.T) {
+	dir := t.TempDir()
+	wav, mp3 := filepath.Join(dir, "talk.wav"), filepath.Join(dir, "talk.mp3")
+	os.WriteFile(wav, nil, 0644)
+	if got, want := sidecarPath(wav, "srt", ""), filepath.Join(dir, "talk.srt"); got != want {
+		t.Errorf("alone: %s, want %s", got, want)
+	}
+	os.WriteFile(mp3, nil, 0644)
+	if got, want := sidecarPath(wav, "srt", ""), filepath.Join(dir, "talk.wav.srt"); got != want {
+		t.Errorf("wav beside mp3: %s, want %s", got, want)
+	}
+	if got, want := sidecarPath(mp3, "json", "out"), filepath.Join("out", "talk.mp3.json"); got != want {
+		t.Errorf("mp3 beside wav: %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"zee/config"
+	"zee/log"
+	"zee/postprocess"
+)
+
+// Dictated text passes through the spoken commands (when enabled in
+// config.json) and replacements.txt on its way to the paste, streamed and
+// batch alike (package postprocess), then — batch only, and only when opted
+// in — the LLM polish pass. The rules are compiled again only when the file's
+// contents change, and a recording keeps the pipeline it started with.
+
+var (
+	rulesMu  sync.Mutex
+	rulesSrc string
+	rules    *postprocess.Rules
+)
+
+// currentRules returns the compiled replacements.txt. A bad rule is logged
+// and skipped, once per edit.
+func currentRules() *postprocess.Rules {
+	src := config.GetReplacements()
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if rules == nil || src != rulesSrc {
+		var errs []error
+		rules, errs = postprocess.ParseRules(src)
+		rulesSrc = src
+		for _, err := range errs {
+			log.Warnf("replacements.txt: %v", err)
+		}
+	}
+	return rules
+}
+
+// currentPipeline is the post-processing for a dictation in lang.
+func currentPipeline(lang string) postprocess.Pipeline {
+	p := postprocess.Pipeline{Rules: currentRules()}
+	if sc := config.Get().SpokenCommands; sc.Enabled {
+		base, _, _ := strings.Cut(lang, "-")
+		p.Commands = postprocess.NewCommands(lang, sc.Phrases[cmp.Or(base, "en")])
+	}
+	return p
+}
+
+// currentPolisher is the polish pass, or nil unless config.json enables it
+// and names an endpoint.
+func currentPolisher() *postprocess.Polisher {
+	pc := config.Get().Polish
+	if !pc.Enabled || strings.TrimSpace(pc.BaseURL) == "" {
+		return nil
+	}
+	return &postprocess.Polisher{
+		ChatEndpoint: postprocess.ChatEndpoint{
+			BaseURL: pc.BaseURL,
+			Model:   pc.Model,
+			APIKey:  config.APIKey("polish"),
+			Budget:  time.Duration(pc.BudgetMs) * time.Millisecond,
+		},
+		Prompt: config.GetPolishPrompt(),
+		Tone:   pc.Tone,
+	}
+}
+
+// polishText runs the polish pass over text and reports how long it took.
+// Whatever goes wrong — budget, network, a useless reply — the text comes back
+// as it was: polish may improve a paste, never block or lose one.
+func polishText(p *postprocess.Polisher, text string) (string, float64) {
+	t := time.Now()
+	out, err := p.Polish(context.Background(), text)
+	ms := float64(time.Since(t).Microseconds()) / 1000
+	if err != nil {
+		log.Warnf("polish: %v; pasting the unpolished text", err)
+		return text, ms
+	}
+	log.Info(fmt.Sprintf("polish ms=%.0f model=%s", ms, p.Model))
+	return out, ms
+}
+
+// logTranscript logs a finished transcript and what post-processing made of
+// it. The diagnostics log only counts the commands and names the rules (by
+// line); the text itself, raw and rewritten, goes to the transcribe log
+// (-debug-transcribe), so each rule's effect can be checked.
+func logTranscript(raw string, out postprocess.Output) {
+	log.TranscriptionText(raw)
+	if out.Commands > 0 {
+		log.Info(fmt.Sprintf("spoken_commands n=%d enter=%v", out.Commands, out.Enter))
+	}
+	if len(out.Rules) > 0 {
+		log.Info(fmt.Sprintf("replacements lines=%v", out.Rules))
+	}
+	if out.Text != raw {
+		log.TranscriptionRewrite(out.Text)
+	}
+}
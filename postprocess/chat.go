@@ -0,0 +1,103 @@
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ChatEndpoint is an OpenAI-compatible chat-completions endpoint (OpenAI,
+// Groq, a local llama server), as the polish pass and command mode use it.
+type ChatEndpoint struct {
+	BaseURL string // what precedes /chat/completions, e.g. "https://api.groq.com/openai/v1"
+	Model   string
+	APIKey  string        // optional: a local server rarely wants one
+	Budget  time.Duration // hard cap on the call; 0 takes the caller's default
+	Client  *http.Client  // nil uses http.DefaultClient
+}
+
+func (e ChatEndpoint) chatURL() string {
+	u := strings.TrimRight(strings.TrimSpace(e.BaseURL), "/")
+	if strings.HasSuffix(u, "/chat/completions") {
+		return u
+	}
+	return u + "/chat/completions"
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// complete sends one system and one user message and returns the reply,
+// trimmed; an empty reply is an error. The call gets e.Budget, or def when
+// that is unset.
+func (e ChatEndpoint) complete(ctx context.Context, def time.Duration, system, user string) (string, error) {
+	budget := e.Budget
+	if budget <= 0 {
+		budget = def
+	}
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	body, err := json.Marshal(map[string]any{
+		"model": e.Model,
+		"messages": []chatMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: user},
+		},
+		"temperature": 0,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.chatURL(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", fmt.Errorf("over the %v budget", budget)
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var reply struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return "", fmt.Errorf("decode reply: %w", err)
+	}
+	if len(reply.Choices) == 0 {
+		return "", errors.New("reply has no choices")
+	}
+	out := strings.TrimSpace(reply.Choices[0].Message.Content)
+	if out == "" {
+		return "", errors.New("empty reply")
+	}
+	return out, nil
+}
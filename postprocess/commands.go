@@ -0,0 +1,398 @@
+package postprocess
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Commands interprets words dictated as structure — "new line", "comma",
+// "all caps … end caps", "scratch that", "press enter" — in the dictation's
+// language. nil interprets nothing.
+//
+// Engines often punctuate the command words themselves ("Hello, comma, how
+// are you. New line."), so phrases are matched ignoring case and the
+// punctuation around each word, and that punctuation goes with the command.
+type Commands struct {
+	phrases map[string]action // normalized words, space-joined
+	span    int               // words in the longest phrase
+	turkish bool              // dotted/dotless i when changing case
+}
+
+type actionKind int
+
+const (
+	actWord    actionKind = iota // text as a word of its own
+	actPunct                     // text attached to the word before
+	actOpen                      // text attached to the word after
+	actNewline                   // text is "\n" or "\n\n"
+	actCapsOn                    // upper-case the words that follow…
+	actCapsOff                   // …until this
+	actScratch                   // drop the phrase before
+	actEnter                     // press Return after the paste
+)
+
+type action struct {
+	kind actionKind
+	text string
+}
+
+// Action names for the value side of a phrase; any other value is text to
+// insert. "open:" before text attaches it to the word after, like "(".
+const (
+	ActionNewline   = "newline"
+	ActionParagraph = "paragraph"
+	ActionCapsOn    = "caps_on"
+	ActionCapsOff   = "caps_off"
+	ActionScratch   = "scratch"
+	ActionEnter     = "enter"
+)
+
+// builtinCommands are the phrases per language, in the action syntax a user
+// can also write in config.json.
+var builtinCommands = map[string]map[string]string{
+	"en": {
+		"new line":          ActionNewline,
+		"newline":           ActionNewline,
+		"next line":         ActionNewline,
+		"new paragraph":     ActionParagraph,
+		"comma":             ",",
+		"period":            ".",
+		"full stop":         ".",
+		"question mark":     "?",
+		"exclamation mark":  "!",
+		"exclamation point": "!",
+		"colon":             ":",
+		"semicolon":         ";",
+		"open paren":        "open:(",
+		"open parenthesis":  "open:(",
+		"close paren":       ")",
+		"close parenthesis": ")",
+		"open quote":        "open:\"",
+		"close quote":       "\"",
+		"all caps":          ActionCapsOn,
+		"end caps":          ActionCapsOff,
+		"scratch that":      ActionScratch,
+		"press enter":       ActionEnter,
+	},
+	"de": {
+		"neue zeile":            ActionNewline,
+		"nächste zeile":         ActionNewline,
+		"neuer absatz":          ActionParagraph,
+		"komma":                 ",",
+		"punkt":                 ".",
+		"fragezeichen":          "?",
+		"ausrufezeichen":        "!",
+		"doppelpunkt":           ":",
+		"semikolon":             ";",
+		"strichpunkt":           ";",
+		"klammer auf":           "open:(",
+		"klammer zu":            ")",
+		"anführungszeichen auf": "open:„",
+		"anführungszeichen zu":  "“",
+		"alles groß":            ActionCapsOn,
+		"ende groß":             ActionCapsOff,
+		"streich das":           ActionScratch,
+		"lösch das":             ActionScratch,
+		"drücke enter":          ActionEnter,
+		"enter drücken":         ActionEnter,
+	},
+	"fr": {
+		"à la ligne":            ActionNewline,
+		"nouvelle ligne":        ActionNewline,
+		"nouveau paragraphe":    ActionParagraph,
+		"virgule":               ",",
+		"point":                 ".",
+		"point d'interrogation": "?",
+		"point d'exclamation":   "!",
+		"deux points":           ":",
+		"deux-points":           ":",
+		"point-virgule":         ";",
+		"point virgule":         ";",
+		"ouvrir la parenthèse":  "open:(",
+		"fermer la parenthèse":  ")",
+		"ouvrir les guillemets": "open:«",
+		"fermer les guillemets": "»",
+		"tout en majuscules":    ActionCapsOn,
+		"fin des majuscules":    ActionCapsOff,
+		"efface ça":             ActionScratch,
+		"annule ça":             ActionScratch,
+		"appuie sur entrée":     ActionEnter,
+	},
+	"es": {
+		"nueva línea":            ActionNewline,
+		"siguiente línea":        ActionNewline,
+		"nuevo párrafo":          ActionParagraph,
+		"coma":                   ",",
+		"punto":                  ".",
+		"signo de interrogación": "?",
+		"signo de exclamación":   "!",
+		"dos puntos":             ":",
+		"punto y coma":           ";",
+		"abrir paréntesis":       "open:(",
+		"cerrar paréntesis":      ")",
+		"abrir comillas":         "open:«",
+		"cerrar comillas":        "»",
+		"todo mayúsculas":        ActionCapsOn,
+		"fin mayúsculas":         ActionCapsOff,
+		"borra eso":              ActionScratch,
+		"tacha eso":              ActionScratch,
+		"pulsa enter":            ActionEnter,
+		"presiona enter":         ActionEnter,
+	},
+	"tr": {
+		"yeni satır":     ActionNewline,
+		"yeni paragraf":  ActionParagraph,
+		"virgül":         ",",
+		"nokta":          ".",
+		"soru işareti":   "?",
+		"ünlem işareti":  "!",
+		"iki nokta":      ":",
+		"noktalı virgül": ";",
+		"parantez aç":    "open:(",
+		"parantez kapat": ")",
+		"tırnak aç":      "open:\"",
+		"tırnak kapat":   "\"",
+		"hepsi büyük":    ActionCapsOn,
+		"büyük bitti":    ActionCapsOff,
+		"bunu sil":       ActionScratch,
+		"enter'a bas":    ActionEnter,
+		"entera bas":     ActionEnter,
+	},
+}
+
+// NewCommands builds the interpreter for lang (a code like "de" or "de-AT";
+// "" — auto-detect — uses English). custom adds phrases or, mapped to "",
+// removes built-in ones. A language without built-ins has only its custom
+// phrases.
+func NewCommands(lang string, custom map[string]string) *Commands {
+	lang, _, _ = strings.Cut(strings.ToLower(lang), "-")
+	if lang == "" {
+		lang = "en"
+	}
+	c := &Commands{phrases: map[string]action{}, turkish: lang == "tr" || lang == "az"}
+	add := func(phrase, value string) {
+		words := strings.Fields(phrase)
+		for i, w := range words {
+			words[i] = c.norm(w)
+		}
+		key := strings.Join(words, " ")
+		if key == "" {
+			return
+		}
+		if value == "" {
+			delete(c.phrases, key)
+			return
+		}
+		c.phrases[key] = parseAction(value)
+	}
+	for p, v := range builtinCommands[lang] {
+		add(p, v)
+	}
+	for p, v := range custom {
+		add(p, v)
+	}
+	for k := range c.phrases {
+		c.span = max(c.span, len(strings.Fields(k)))
+	}
+	return c
+}
+
+func parseAction(v string) action {
+	switch v {
+	case ActionNewline:
+		return action{kind: actNewline, text: "\n"}
+	case ActionParagraph:
+		return action{kind: actNewline, text: "\n\n"}
+	case ActionCapsOn:
+		return action{kind: actCapsOn}
+	case ActionCapsOff:
+		return action{kind: actCapsOff}
+	case ActionScratch:
+		return action{kind: actScratch}
+	case ActionEnter:
+		return action{kind: actEnter}
+	}
+	if rest, ok := strings.CutPrefix(v, "open:"); ok {
+		return action{kind: actOpen, text: rest}
+	}
+	if strings.IndexFunc(v, func(r rune) bool { return !unicode.IsPunct(r) || unicode.Is(unicode.Pd, r) }) < 0 {
+		return action{kind: actPunct, text: v} // ",", "?!", …; a dash stays a word
+	}
+	return action{kind: actWord, text: v}
+}
+
+// norm is a word as phrases are matched: lower case, without the punctuation
+// an engine put around it.
+func (c *Commands) norm(w string) string {
+	w = strings.TrimFunc(w, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) })
+	if c.turkish {
+		return strings.ToLowerSpecial(unicode.TurkishCase, w)
+	}
+	return strings.ToLower(w)
+}
+
+func (c *Commands) upper(w string) string {
+	if c.turkish {
+		return strings.ToUpperSpecial(unicode.TurkishCase, w)
+	}
+	return strings.ToUpper(w)
+}
+
+// match is a phrase found at words[start:end].
+type match struct {
+	start, end int
+	act        action
+}
+
+// matches finds the phrases in words, longest first at each position.
+func (c *Commands) matches(words []string) []match {
+	var ms []match
+	for i := 0; i < len(words); {
+		found := false
+		for n := min(c.span, len(words)-i); n > 0; n-- {
+			key := make([]string, n)
+			for j := range n {
+				key[j] = c.norm(words[i+j])
+			}
+			if a, ok := c.phrases[strings.Join(key, " ")]; ok {
+				ms = append(ms, match{i, i + n, a})
+				i += n
+				found = true
+				break
+			}
+		}
+		if !found {
+			i++
+		}
+	}
+	return ms
+}
+
+// Apply interprets text and reports whether it ended in "press enter" (or
+// said it anywhere: Return can only be pressed once the text is pasted) and
+// how many commands it ran.
+func (c *Commands) Apply(text string) (out string, enter bool, n int) {
+	if c == nil || len(c.phrases) == 0 {
+		return text, false, 0
+	}
+	words := strings.Fields(text)
+	ms := c.matches(words)
+	if len(ms) == 0 {
+		return text, false, 0 // keep the engine's own spacing and line breaks
+	}
+	w := &cmdWriter{c: c}
+	for i, m := 0, 0; i < len(words); {
+		if m < len(ms) && ms[m].start == i {
+			w.do(ms[m].act)
+			enter = enter || ms[m].act.kind == actEnter
+			i = ms[m].end
+			m++
+			n++
+			continue
+		}
+		w.word(words[i])
+		i++
+	}
+	return w.String(), enter, n
+}
+
+// Stable is the part of Apply's output for a growing streamed transcript that
+// later words can no longer change: held back are the last words (the next
+// update could complete a phrase there), trailing punctuation and space (a
+// punctuation command replaces it) and, when "scratch that" is a phrase, the
+// last phrase it could take back.
+func (c *Commands) Stable(text string) string {
+	if c == nil || len(c.phrases) == 0 {
+		return text
+	}
+	words := strings.Fields(text)
+	cut := len(words) - c.span
+	for _, m := range c.matches(words) {
+		if m.start < cut && m.end > cut {
+			cut = m.start
+		}
+	}
+	if cut <= 0 {
+		return ""
+	}
+	out, _, _ := c.Apply(strings.Join(words[:cut], " "))
+	if c.has(actScratch) {
+		return out[:scratchPoint(out)]
+	}
+	return strings.TrimRightFunc(out, isTrailingPunct)
+}
+
+func (c *Commands) has(k actionKind) bool {
+	for _, a := range c.phrases {
+		if a.kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrailingPunct is what a punctuation command replaces at the end of the
+// text so far: the engine's own punctuation and the space after it, not a
+// line break.
+func isTrailingPunct(r rune) bool { return r == ' ' || r == '\t' || strings.ContainsRune(".,;:!?", r) }
+
+func isTrailing(r rune) bool { return unicode.IsSpace(r) || isTrailingPunct(r) }
+
+// scratchPoint is where "scratch that" cuts out: after the sentence end or
+// line break before the last phrase.
+func scratchPoint(out string) int {
+	s := strings.TrimRightFunc(out, isTrailing)
+	return strings.LastIndexAny(s, ".!?\n") + 1
+}
+
+// cmdWriter assembles the interpreted text.
+type cmdWriter struct {
+	c    *Commands
+	b    strings.Builder
+	caps bool
+	glue bool // the next word attaches without a space
+}
+
+func (w *cmdWriter) String() string { return w.b.String() }
+
+func (w *cmdWriter) reset(s string) {
+	w.b.Reset()
+	w.b.WriteString(s)
+}
+
+func (w *cmdWriter) word(s string) {
+	if w.caps {
+		s = w.c.upper(s)
+	}
+	if w.b.Len() > 0 && !w.glue {
+		w.b.WriteByte(' ')
+	}
+	w.b.WriteString(s)
+	w.glue = false
+}
+
+func (w *cmdWriter) do(a action) {
+	switch a.kind {
+	case actWord:
+		w.word(a.text)
+	case actPunct:
+		// Replaces what the engine guessed at this pause ("Hello, comma").
+		w.reset(strings.TrimRightFunc(w.String(), isTrailingPunct) + a.text)
+		w.glue = false
+	case actOpen:
+		w.word(a.text)
+		w.glue = true
+	case actNewline:
+		w.reset(strings.TrimRightFunc(w.String(), unicode.IsSpace) + a.text)
+		w.glue = true
+	case actCapsOn:
+		w.caps = true
+	case actCapsOff:
+		w.caps = false
+	case actScratch:
+		out := w.String()
+		w.reset(out[:scratchPoint(out)])
+		w.glue = w.b.Len() == 0 || strings.HasSuffix(w.String(), "\n")
+	case actEnter:
+	}
+}
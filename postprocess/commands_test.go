@@ -0,0 +1,102 @@
+package postprocess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommandsApply(t *testing.T) {
+	c := NewCommands("en", nil)
+	for _, tc := range []struct {
+		in, want string
+		enter    bool
+	}{
+		{"hello comma how are you question mark", "hello, how are you?", false},
+		{"Hello, comma, how are you. Question mark.", "Hello, how are you?", false},
+		{"dear Sam new line thanks", "dear Sam\nthanks", false},
+		{"one. New paragraph. Two.", "one.\n\nTwo.", false},
+		{"this is all caps very important end caps ok", "this is VERY IMPORTANT ok", false},
+		{"First part. Second bit scratch that third bit", "First part. third bit", false},
+		{"scratch that hello", "hello", false},
+		{"call me open paren maybe close paren", "call me (maybe)", false},
+		{"ship it press enter", "ship it", true},
+		{"Nothing  to\ndo here.", "Nothing  to\ndo here.", false},
+	} {
+		got, enter, _ := c.Apply(tc.in)
+		if got != tc.want || enter != tc.enter {
+			t.Errorf("Apply(%q) = %q, %v; want %q, %v", tc.in, got, enter, tc.want, tc.enter)
+		}
+	}
+	if _, _, n := c.Apply("a comma b period"); n != 2 {
+		t.Errorf("commands run = %d, want 2", n)
+	}
+}
+
+func TestCommandsLanguages(t *testing.T) {
+	for _, tc := range []struct{ lang, in, want string }{
+		{"de-AT", "Hallo Komma wie geht's Fragezeichen neue Zeile Tschüss", "Hallo, wie geht's?\nTschüss"},
+		{"fr", "bonjour virgule ça va point d'interrogation", "bonjour, ça va?"},
+		{"es", "hola coma qué tal punto y coma", "hola, qué tal;"},
+		// Turkish cases the dotted İ: "İKİ NOKTA" is "iki nokta", and caps
+		// make "istanbul" İSTANBUL.
+		{"tr", "Saat İKİ NOKTA hepsi büyük istanbul büyük bitti", "Saat: İSTANBUL"},
+		{"", "auto comma detect", "auto, detect"},
+	} {
+		if got, _, _ := NewCommands(tc.lang, nil).Apply(tc.in); got != tc.want {
+			t.Errorf("%s: Apply(%q) = %q, want %q", tc.lang, tc.in, got, tc.want)
+		}
+	}
+	// Another language's phrases are just words.
+	if got, _, _ := NewCommands("de", nil).Apply("hello comma there"); got != "hello comma there" {
+		t.Errorf("de interpreted English: %q", got)
+	}
+}
+
+func TestCommandsCustom(t *testing.T) {
+	c := NewCommands("en", map[string]string{
+		"period":        "",    // removed: too common a word
+		"dot":           ".",   // added
+		"smiley":        ":-)", // inserted as a word
+		"Zeilenumbruch": "newline",
+	})
+	got, _, _ := c.Apply("the period ended dot smiley zeilenumbruch next")
+	if want := "the period ended. :-)\nnext"; got != want {
+		t.Errorf("Apply = %q, want %q", got, want)
+	}
+	var nilCmds *Commands
+	if got, _, _ := nilCmds.Apply("a comma b"); got != "a comma b" {
+		t.Errorf("nil Commands changed text: %q", got)
+	}
+}
+
+// Whatever Stable pastes while a transcript grows must be a prefix of the
+// final result, or the paste would need taking back.
+func TestCommandsStable(t *testing.T) {
+	c := NewCommands("en", nil)
+	for _, final := range []string{
+		"Hello, comma, how are you. New line. Fine, thanks. Period.",
+		"write all caps this end caps now question mark press enter",
+		"First idea. Second idea scratch that third idea.",
+	} {
+		want, _, _ := c.Apply(final)
+		words := strings.Fields(final)
+		for i := range words {
+			partial := strings.Join(words[:i+1], " ")
+			if got := c.Stable(partial); !strings.HasPrefix(want, got) {
+				t.Errorf("Stable(%q) = %q, not a prefix of %q", partial, got, want)
+			}
+		}
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	r, _ := ParseRules("get hub => GitHub\n")
+	p := Pipeline{Commands: NewCommands("en", nil), Rules: r}
+	out := p.Apply("push to get hub period press enter")
+	if out.Text != "push to GitHub." || !out.Enter || out.Commands != 2 || len(out.Rules) != 1 {
+		t.Errorf("Apply = %+v", out)
+	}
+	if got := (Pipeline{}).Apply("as is comma"); got.Text != "as is comma" || got.Commands != 0 {
+		t.Errorf("empty pipeline = %+v", got)
+	}
+}
@@ -0,0 +1,31 @@
+package postprocess
+
+// Pipeline is what a dictation passes through on its way to the paste:
+// spoken commands, then the replacement rules. A nil stage is skipped.
+type Pipeline struct {
+	Commands *Commands
+	Rules    *Rules
+}
+
+// Output is a processed transcript.
+type Output struct {
+	Text     string
+	Enter    bool  // press Return after pasting Text
+	Commands int   // spoken commands run
+	Rules    []int // replacements.txt lines that changed the text
+}
+
+// Apply processes a finished transcript.
+func (p Pipeline) Apply(text string) Output {
+	var out Output
+	out.Text, out.Enter, out.Commands = p.Commands.Apply(text)
+	out.Text, out.Rules = p.Rules.Apply(out.Text)
+	return out
+}
+
+// Stable processes a growing streamed transcript, returning only what later
+// updates can't change — always a prefix of Apply's text for the finished
+// transcript, barring a command that takes back more than the last phrase.
+func (p Pipeline) Stable(text string) string {
+	return p.Rules.Stable(p.Commands.Stable(text))
+}
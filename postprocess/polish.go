@@ -0,0 +1,54 @@
+package postprocess
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Polisher is the optional LLM cleanup pass: the finished transcript goes to
+// a chat endpoint with the user's prompt, and the reply is pasted instead.
+// The user is waiting on the paste, so the call has a hard budget; past it,
+// or on any error, the caller keeps the text it has. nil polishes nothing.
+type Polisher struct {
+	ChatEndpoint
+	Prompt string // system message; see PolishPrompt
+	Tone   string // fills {tone} in Prompt
+}
+
+// DefaultPolishBudget bounds the call when config.json sets no budget_ms:
+// about what a small hosted model needs for a dictation-length reply.
+const DefaultPolishBudget = 1500 * time.Millisecond
+
+// DefaultTone fills {tone} when config.json sets none.
+const DefaultTone = "neutral"
+
+// PolishPrompt turns polish.txt into the system message: lines starting with
+// # are dropped, {tone} is filled in.
+func PolishPrompt(template, tone string) string {
+	var lines []string
+	for _, line := range strings.Split(template, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	if tone == "" {
+		tone = DefaultTone
+	}
+	return strings.ReplaceAll(strings.TrimSpace(strings.Join(lines, "\n")), "{tone}", tone)
+}
+
+// Polish returns text as the model rewrote it. A reply far longer than the
+// dictation (the model answered it instead of cleaning it up) is an error
+// like a timeout: the caller falls back to text.
+func (p *Polisher) Polish(ctx context.Context, text string) (string, error) {
+	out, err := p.complete(ctx, DefaultPolishBudget, PolishPrompt(p.Prompt, p.Tone), text)
+	if err != nil {
+		return "", err
+	}
+	if len(out) > 2*len(text)+100 {
+		return "", fmt.Errorf("reply is %d bytes for %d dictated", len(out), len(text))
+	}
+	return out, nil
+}
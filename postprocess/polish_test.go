@@ -0,0 +1,91 @@
+package postprocess
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func chatServer(t *testing.T, reply string, delay time.Duration, got *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if got != nil {
+			json.NewDecoder(r.Body).Decode(got)
+			(*got)["auth"] = r.Header.Get("Authorization")
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": reply}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPolish(t *testing.T) {
+	var req map[string]any
+	srv := chatServer(t, " So, ship it on Friday. \n", 0, &req)
+	p := &Polisher{
+		ChatEndpoint: ChatEndpoint{BaseURL: srv.URL + "/v1/", Model: "small", APIKey: "k"},
+		Prompt:       "# comment\nClean this up; make it {tone}.",
+		Tone:         "friendly",
+	}
+	out, err := p.Polish(context.Background(), "um so uh ship it on friday")
+	if err != nil || out != "So, ship it on Friday." {
+		t.Fatalf("Polish = %q, %v", out, err)
+	}
+	msgs, _ := req["messages"].([]any)
+	if req["model"] != "small" || req["auth"] != "Bearer k" || len(msgs) != 2 {
+		t.Fatalf("request = %v", req)
+	}
+	if sys := msgs[0].(map[string]any)["content"]; sys != "Clean this up; make it friendly." {
+		t.Errorf("system prompt = %q", sys)
+	}
+	if user := msgs[1].(map[string]any)["content"]; user != "um so uh ship it on friday" {
+		t.Errorf("user message = %q", user)
+	}
+}
+
+func TestPolishFallbacks(t *testing.T) {
+	const text = "what time is it"
+	for name, c := range map[string]struct {
+		reply string
+		delay time.Duration
+		path  string
+		want  string
+	}{
+		"over budget": {reply: "What time is it?", delay: 400 * time.Millisecond, want: "budget"},
+		"empty reply": {reply: "  ", want: "empty"},
+		"answered":    {reply: strings.Repeat("It is three o'clock. ", 20), want: "bytes"},
+		"bad status":  {reply: "x", path: "/nope", want: "HTTP 404"},
+	} {
+		srv := chatServer(t, c.reply, c.delay, nil)
+		p := &Polisher{ChatEndpoint: ChatEndpoint{BaseURL: srv.URL + "/v1" + c.path, Budget: 100 * time.Millisecond}}
+		start := time.Now()
+		out, err := p.Polish(context.Background(), text)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: Polish = %q, %v; want error containing %q", name, out, err, c.want)
+		}
+		if d := time.Since(start); d > 500*time.Millisecond {
+			t.Errorf("%s: took %v with a 100ms budget", name, d)
+		}
+	}
+}
+
+func TestPolishPromptDefaultTone(t *testing.T) {
+	if got := PolishPrompt("be {tone}", ""); got != "be "+DefaultTone {
+		t.Errorf("PolishPrompt = %q", got)
+	}
+}
@@ -0,0 +1,41 @@
+package postprocess
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Rewriter is command mode's model: it applies a spoken instruction ("make
+// this more formal", "translate to German") to the selected text.
+type Rewriter struct {
+	ChatEndpoint
+}
+
+// DefaultRewriteBudget bounds the call when config.json sets no budget_ms.
+// Longer than polish's: the user asked for this edit and waits for it, and
+// there is nothing to paste without it.
+const DefaultRewriteBudget = 15 * time.Second
+
+const rewritePrompt = `You edit text on request. The user message is an instruction followed by the text to apply it to, between <text> and </text>. Reply with the edited text only: no preamble, no explanation, no quotes or tags around it. Keep the text's language, line breaks and formatting unless the instruction asks to change them.`
+
+// Rewrite returns selection with instruction applied. The selection's
+// trailing line break, which the reply tends to drop, is kept.
+func (r *Rewriter) Rewrite(ctx context.Context, selection, instruction string) (string, error) {
+	user := "Instruction: " + strings.TrimSpace(instruction) + "\n\n<text>\n" + selection + "\n</text>"
+	out, err := r.complete(ctx, DefaultRewriteBudget, rewritePrompt, user)
+	if err != nil {
+		return "", err
+	}
+	if s, ok := strings.CutPrefix(out, "<text>"); ok {
+		if s, ok = strings.CutSuffix(s, "</text>"); ok {
+			out = strings.TrimSpace(s)
+		}
+	}
+	if tail := selection[len(strings.TrimRightFunc(selection, isNewline)):]; tail != "" {
+		out += tail
+	}
+	return out, nil
+}
+
+func isNewline(r rune) bool { return r == '\n' || r == '\r' }
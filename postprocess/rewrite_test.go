@@ -0,0 +1,22 @@
+package postprocess
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	var req map[string]any
+	srv := chatServer(t, "<text>\nDear Sir or Madam,\n</text>", 0, &req)
+	r := &Rewriter{ChatEndpoint{BaseURL: srv.URL + "/v1"}}
+	out, err := r.Rewrite(context.Background(), "hey you\n", " Make this more formal. ")
+	if err != nil || out != "Dear Sir or Madam,\n" {
+		t.Fatalf("Rewrite = %q, %v", out, err)
+	}
+	msgs, _ := req["messages"].([]any)
+	user, _ := msgs[1].(map[string]any)["content"].(string)
+	if !strings.HasPrefix(user, "Instruction: Make this more formal.\n") || !strings.Contains(user, "<text>\nhey you\n\n</text>") {
+		t.Errorf("user message = %q", user)
+	}
+}
@@ -0,0 +1,224 @@
+// Package postprocess rewrites a transcript between the provider and the
+// paste: spoken commands ("comma", "new line", "scratch that") and the user's
+// replacements.txt fixes for words a model keeps mishearing.
+package postprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rules is a parsed replacements.txt. The zero value (and nil) changes
+// nothing.
+type Rules struct {
+	rules []rule
+	span  int
+}
+
+type rule struct {
+	line     int // 1-based, for logs and errors
+	re       *regexp.Regexp
+	to       string
+	regex    bool // to may hold $1-style group references
+	word     bool // only where the match is a whole word run
+	keepCase bool // to takes the case of the text it replaces
+}
+
+// regexSpan is how many trailing words a regex rule is assumed to reach across
+// when streamed text is held back (see Stable): a pattern's reach can't be
+// read off it, and the multi-word fixes people write rarely exceed this.
+const regexSpan = 3
+
+// ParseRules parses replacements.txt. A bad line is reported and skipped; the
+// rest still apply.
+//
+//	get hub => GitHub       literal, any case, inserted as written
+//	~teh => the             case-preserving
+//	<js> => JavaScript      whole words only
+//	/colou?r/i => color     RE2 regex; flags: i
+func ParseRules(src string) (*Rules, []error) {
+	var (
+		r    = &Rules{}
+		errs []error
+	)
+	for i, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ru, span, err := parseRule(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", i+1, err))
+			continue
+		}
+		ru.line = i + 1
+		r.rules = append(r.rules, ru)
+		r.span = max(r.span, span)
+	}
+	return r, errs
+}
+
+// parseRule parses one rule and returns how many words its match can span.
+func parseRule(line string) (rule, int, error) {
+	if strings.HasPrefix(line, "/") {
+		return parseRegexRule(line)
+	}
+	from, to, ok := strings.Cut(line, "=>")
+	if !ok {
+		return rule{}, 0, fmt.Errorf("no => in %q", line)
+	}
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	var ru rule
+	if rest, ok := strings.CutPrefix(from, "~"); ok {
+		ru.keepCase, from = true, rest
+	}
+	if strings.HasPrefix(from, "<") && strings.HasSuffix(from, ">") && len(from) > 2 {
+		ru.word, from = true, from[1:len(from)-1]
+	}
+	words := strings.Fields(from)
+	if len(words) == 0 {
+		return rule{}, 0, fmt.Errorf("nothing to replace in %q", line)
+	}
+	// Words match across any run of spaces: engines differ in what they emit.
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	ru.re = regexp.MustCompile(`(?i)` + strings.Join(words, `\s+`))
+	ru.to = to
+	return ru, len(words), nil
+}
+
+func parseRegexRule(line string) (rule, int, error) {
+	// The pattern may itself hold "=>": the separator is the first one that
+	// follows a closing slash (and flags).
+	for off := 0; ; {
+		i := strings.Index(line[off:], "=>")
+		if i < 0 {
+			return rule{}, 0, fmt.Errorf("no /pattern/ => in %q", line)
+		}
+		i += off
+		off = i + 2
+		pat := strings.TrimSpace(line[:i])
+		flags := ""
+		if strings.HasSuffix(pat, "/i") {
+			pat, flags = pat[:len(pat)-1], "(?i)"
+		}
+		if len(pat) < 3 || !strings.HasSuffix(pat, "/") {
+			continue
+		}
+		re, err := regexp.Compile(flags + pat[1:len(pat)-1])
+		if err != nil {
+			return rule{}, 0, err
+		}
+		return rule{re: re, to: strings.TrimSpace(line[i+2:]), regex: true}, regexSpan, nil
+	}
+}
+
+// Apply runs the rules over text in order and returns the result and the line
+// numbers of the rules that changed it.
+func (r *Rules) Apply(text string) (string, []int) {
+	if r == nil {
+		return text, nil
+	}
+	var hit []int
+	for _, ru := range r.rules {
+		if out := ru.apply(text); out != text {
+			text = out
+			hit = append(hit, ru.line)
+		}
+	}
+	return text, hit
+}
+
+func (ru rule) apply(text string) string {
+	if ru.regex {
+		return ru.re.ReplaceAllString(text, ru.to)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range ru.re.FindAllStringIndex(text, -1) {
+		if ru.word && !wholeWord(text, m[0], m[1]) {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		if ru.keepCase {
+			b.WriteString(matchCase(ru.to, text[m[0]:m[1]]))
+		} else {
+			b.WriteString(ru.to)
+		}
+		last = m[1]
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func isWordRune(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+
+// wholeWord reports whether text[start:end] has no word character on either
+// side. Unicode-aware, unlike RE2's ASCII-only \b.
+func wholeWord(text string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(r) {
+		return false
+	}
+	return true
+}
+
+// matchCase gives to the case of matched: all caps stays all caps, a capital
+// first letter stays capital, anything else leaves to as written.
+func matchCase(to, matched string) string {
+	letters := strings.IndexFunc(matched, unicode.IsLetter) >= 0
+	switch {
+	case to == "" || !letters:
+		return to
+	case utf8.RuneCountInString(matched) > 1 && matched == strings.ToUpper(matched):
+		return strings.ToUpper(to)
+	}
+	first, _ := utf8.DecodeRuneInString(strings.TrimLeftFunc(matched, func(r rune) bool { return !unicode.IsLetter(r) }))
+	if unicode.IsUpper(first) {
+		r, n := utf8.DecodeRuneInString(to)
+		return string(unicode.ToUpper(r)) + to[n:]
+	}
+	return to
+}
+
+// Stable returns the part of a growing streamed transcript that can be pasted
+// now, with the rules applied: the text minus its last few words — as many as
+// the longest rule spans, since the next update could still complete a match
+// there — and never cutting through a match, which must be rewritten whole.
+// With no rules it is the whole text, unchanged. Apply on the final text
+// delivers what was held back.
+func (r *Rules) Stable(text string) string {
+	if r == nil || len(r.rules) == 0 {
+		return text
+	}
+	cut := len(text)
+	for range r.span {
+		// Back over trailing space, then the word before it.
+		cut = len(strings.TrimRightFunc(text[:cut], unicode.IsSpace))
+		cut = strings.LastIndexFunc(text[:cut], unicode.IsSpace) + 1
+		if cut == 0 {
+			return ""
+		}
+	}
+	for moved := true; moved; {
+		moved = false
+		for _, ru := range r.rules {
+			for _, m := range ru.re.FindAllStringIndex(text, -1) {
+				if m[0] < cut && m[1] > cut {
+					cut, moved = m[0], true
+				}
+			}
+		}
+	}
+	out, _ := r.Apply(text[:cut])
+	return out
+}
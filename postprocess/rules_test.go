@@ -0,0 +1,94 @@
+package postprocess
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testRules = `# comment
+get hub => GitHub
+kuber netties => Kubernetes
+~teh => the
+<js> => JavaScript
+/(\d+) percent/ => $1%
+/colou?r/i => color
+um =>
+`
+
+func TestApply(t *testing.T) {
+	r, errs := ParseRules(testRules)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	for _, c := range []struct{ in, want string }{
+		{"push it to get hub", "push it to GitHub"},
+		{"Get  Hub is down", "GitHub is down"},
+		{"deploy on kuber netties", "deploy on Kubernetes"},
+		{"teh cat", "the cat"},
+		{"Teh cat", "The cat"},
+		{"TEH CAT", "THE CAT"},
+		{"write js, not jsx", "write JavaScript, not jsx"},
+		{"ajs js", "ajs JavaScript"},
+		{"up 12 percent", "up 12%"},
+		{"the Colour red", "the color red"},
+		{"so um yes", "so  yes"},
+		{"nothing here", "nothing here"},
+	} {
+		if got, _ := r.Apply(c.in); got != c.want {
+			t.Errorf("Apply(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	if _, hit := r.Apply("teh get hub"); !slices.Equal(hit, []int{2, 4}) {
+		t.Errorf("hit lines = %v, want [2 4]", hit)
+	}
+}
+
+func TestParseRulesErrors(t *testing.T) {
+	r, errs := ParseRules("no arrow here\n/unclosed => x\n/a(/ => x\n=> empty\nok => fine\n")
+	if len(errs) != 4 {
+		t.Fatalf("errors = %v, want 4", errs)
+	}
+	for i, line := range []string{"line 1", "line 2", "line 3", "line 4"} {
+		if !strings.HasPrefix(errs[i].Error(), line) {
+			t.Errorf("error %d = %v", i, errs[i])
+		}
+	}
+	if got, _ := r.Apply("ok"); got != "fine" {
+		t.Errorf("good rule after bad ones = %q", got)
+	}
+}
+
+func TestRegexSeparator(t *testing.T) {
+	r, errs := ParseRules("/a=>b/ => arrow")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if got, _ := r.Apply("x a=>b y"); got != "x arrow y" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// TestStable streams a transcript word by word and checks the pasted prefix
+// never has to change and ends equal to the batch result.
+func TestStable(t *testing.T) {
+	r, _ := ParseRules(testRules)
+	words := strings.Fields("we use get hub and kuber netties daily")
+	var pasted, text string
+	for _, w := range words {
+		text += w + " "
+		s := r.Stable(text)
+		if !strings.HasPrefix(s, pasted) {
+			t.Fatalf("Stable(%q) = %q, no longer extends %q", text, s, pasted)
+		}
+		pasted = s
+	}
+	final, _ := r.Apply(text)
+	if !strings.HasPrefix(final, pasted) || final != "we use GitHub and Kubernetes daily " {
+		t.Errorf("pasted %q, final %q", pasted, final)
+	}
+	var none *Rules
+	if got := none.Stable("a b"); got != "a b" {
+		t.Errorf("nil rules Stable = %q", got)
+	}
+}
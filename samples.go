@@ -0,0 +1,415 @@
+package main
+
+import (
+	"archive/zip"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"zee/archive"
+	"zee/config"
+	"zee/transcriber"
+)
+
+// `zee samples` works on saved recordings — samples/, or the archive with
+// -archive — through the same direct-transcribe path as the fallback chain:
+// list them, retry failures, compare a stored transcript with another
+// provider's, and export them as a labeled dataset.
+
+const samplesUsage = `Usage:
+  zee samples [list] [-archive] [-failed]        saved recordings, oldest first
+  zee samples retry [-provider P] [-model M] [-all] [name...]
+      re-transcribe failed recordings (default: the newest) and copy the text
+  zee samples retranscribe -provider P [-model M] [-failed] [name...]
+      each stored transcript next to a new one, with how many words differ
+  zee samples export -o dataset.zip [-archive] [name...]
+      audio and transcripts as audio/… plus metadata.jsonl
+
+retry uses config.json's current provider unless -provider is given;
+retranscribe needs one. A name is a recording's folder, as list shows it.
+`
+
+// sampleInfo is the part of a recording's info.json the commands use; samples/
+// and the archive share these keys.
+type sampleInfo struct {
+	Timestamp string `json:"timestamp"`
+	Provider  string `json:"provider"`
+	Model     string `json:"model"`
+	Format    string `json:"format"`
+	Language  string `json:"language,omitempty"`
+	Hints     string `json:"hints,omitempty"`
+	Text      string `json:"text"`
+	Error     string `json:"error,omitempty"`
+}
+
+// sample is one saved recording.
+type sample struct {
+	dir  string
+	info sampleInfo
+	read func(folder, name string) ([]byte, error)
+}
+
+func (s sample) name() string      { return filepath.Base(s.dir) }
+func (s sample) audioName() string { return "audio." + s.info.Format }
+
+func (s sample) audio() ([]byte, error) { return s.read(s.dir, s.audioName()) }
+
+// samplesEnv is what the commands reach outside the sample folders:
+// providerByName and the clipboard in the app, fakes in tests.
+type samplesEnv struct {
+	resolve func(string) (transcriber.ProviderInfo, bool)
+	copy    func(string) error
+}
+
+func readPlain(folder, name string) ([]byte, error) { return os.ReadFile(filepath.Join(folder, name)) }
+
+// loadSamples reads every recording under samples/, or the archive, oldest
+// first. A folder without a readable info.json is not a recording.
+func loadSamples(fromArchive bool) ([]sample, error) {
+	dir, read := filepath.Join(config.Dir(), "samples"), readPlain
+	if fromArchive {
+		archiveMu.Lock()
+		a, err := openArchive()
+		archiveMu.Unlock()
+		if err != nil {
+			return nil, fmt.Errorf("archive: %w", err)
+		}
+		dir, read = a.Dir(), a.ReadFile
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var out []sample
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		s := sample{dir: filepath.Join(dir, e.Name()), read: read}
+		data, err := read(s.dir, "info.json")
+		if errors.Is(err, archive.ErrSealed) || errors.Is(err, archive.ErrPassphrase) {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		if err != nil || json.Unmarshal(data, &s.info) != nil {
+			continue
+		}
+		s.info.Format = cmp.Or(s.info.Format, "wav")
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// pickSamples narrows all to the named recordings, in the order named.
+func pickSamples(all []sample, names []string) ([]sample, error) {
+	if len(names) == 0 {
+		return all, nil
+	}
+	var out []sample
+	for _, n := range names {
+		i := slices.IndexFunc(all, func(s sample) bool { return s.name() == filepath.Base(n) })
+		if i < 0 {
+			return nil, fmt.Errorf("no recording %q", n)
+		}
+		out = append(out, all[i])
+	}
+	return out, nil
+}
+
+func failedSamples(all []sample) []sample {
+	return slices.DeleteFunc(slices.Clone(all), func(s sample) bool { return s.info.Error == "" })
+}
+
+// runSamples is the `zee samples` verb. It returns the exit code.
+func runSamples(args []string, env samplesEnv, stdout, stderr io.Writer) int {
+	sub := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("zee samples "+sub, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, samplesUsage) }
+	fromArchive := fs.Bool("archive", false, "use the archive instead of samples/")
+	onlyFailed := fs.Bool("failed", false, "only recordings whose transcription failed")
+	all := fs.Bool("all", false, "retry every failed recording, not just the newest")
+	provider := fs.String("provider", "", "provider to transcribe with (default: the saved one)")
+	model := fs.String("model", "", "model for -provider (default: its default)")
+	outPath := fs.String("o", "", "export: the zip file to write")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	samples, err := loadSamples(*fromArchive)
+	if err == nil {
+		samples, err = pickSamples(samples, fs.Args())
+	}
+	if err == nil && *onlyFailed {
+		samples = failedSamples(samples)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "zee samples: %v\n", err)
+		return 1
+	}
+
+	switch sub {
+	case "list":
+		for _, s := range samples {
+			state := oneLine(s.info.Text, 60)
+			if s.info.Error != "" {
+				state = "FAILED " + oneLine(s.info.Error, 53)
+			}
+			fmt.Fprintf(stdout, "%s  %-32s %s\n", s.name(), oneLine(s.info.Provider+"/"+s.info.Model, 32), state)
+		}
+		if len(samples) == 0 {
+			fmt.Fprintln(stderr, "no recordings found")
+		}
+	case "retry":
+		if fs.NArg() == 0 {
+			samples = failedSamples(samples)
+			if !*all && len(samples) > 1 {
+				samples = samples[len(samples)-1:]
+			}
+		}
+		err = retrySamples(samples, env, *provider, *model, stdout, stderr)
+	case "retranscribe":
+		if *provider == "" {
+			fmt.Fprintln(stderr, "zee samples retranscribe: -provider is required")
+			return 2
+		}
+		err = retranscribeSamples(samples, env, *provider, *model, stdout, stderr)
+	case "export":
+		if *outPath == "" {
+			fmt.Fprintln(stderr, "zee samples export: -o is required")
+			return 2
+		}
+		var n int
+		if n, err = exportSamples(samples, *outPath); err == nil {
+			fmt.Fprintf(stdout, "%d recordings written to %s\n", n, *outPath)
+		}
+	default:
+		fmt.Fprint(stderr, samplesUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "zee samples: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// sampleTranscriber resolves provider and model — config.json's when
+// provider is "" — to a direct transcriber. The caller closes it.
+func sampleTranscriber(env samplesEnv, provider, model string) (transcriber.Transcriber, directTranscriber, error) {
+	if provider == "" {
+		cfg := config.Get()
+		provider, model = cfg.Provider, cmp.Or(model, cfg.Model)
+	}
+	if provider == "" {
+		return nil, nil, errors.New("no provider configured; pass -provider")
+	}
+	p, ok := env.resolve(provider)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown provider %q", provider)
+	}
+	if !p.Available() {
+		return nil, nil, fmt.Errorf("%s is not available (no key or no model downloaded)", provider)
+	}
+	tr := p.New()
+	if model != "" {
+		tr.SetModel(model)
+	}
+	dt, ok := tr.(directTranscriber)
+	if !ok {
+		closeFallback(tr)
+		return nil, nil, fmt.Errorf("%s can't transcribe a file", provider)
+	}
+	return tr, dt, nil
+}
+
+// transcribeSample runs s's audio through dt in the language and with the
+// hints it was recorded with, where info.json has them.
+func transcribeSample(s sample, dt directTranscriber) (string, error) {
+	audio, err := s.audio()
+	if err != nil {
+		return "", err
+	}
+	lang := s.info.Language
+	if lang == "" {
+		lang = config.Get().Language
+	}
+	res, err := dt.Transcribe(audio, s.info.Format, lang, cmp.Or(s.info.Hints, config.GetHints()))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res.Text), nil
+}
+
+// retrySamples transcribes samples again and copies what came back, joined
+// in recording order, to the clipboard.
+func retrySamples(samples []sample, env samplesEnv, provider, model string, stdout, stderr io.Writer) error {
+	if len(samples) == 0 {
+		return errors.New("no failed recordings to retry")
+	}
+	tr, dt, err := sampleTranscriber(env, provider, model)
+	if err != nil {
+		return err
+	}
+	defer closeFallback(tr)
+	var texts []string
+	failed := 0
+	for _, s := range samples {
+		text, err := transcribeSample(s, dt)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", s.name(), err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "%s  %s\n", s.name(), text)
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) > 0 {
+		if err := env.copy(strings.Join(texts, "\n")); err != nil {
+			return fmt.Errorf("copy: %w", err)
+		}
+		fmt.Fprintf(stderr, "copied %d transcript(s) from %s/%s\n", len(texts), tr.Name(), tr.GetModel())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d recordings failed again", failed, len(samples))
+	}
+	return nil
+}
+
+// retranscribeSamples prints, per recording, the stored transcript and a new
+// one from provider, and how far apart they are.
+func retranscribeSamples(samples []sample, env samplesEnv, provider, model string, stdout, stderr io.Writer) error {
+	tr, dt, err := sampleTranscriber(env, provider, model)
+	if err != nil {
+		return err
+	}
+	defer closeFallback(tr)
+	source := tr.Name() + "/" + tr.GetModel()
+	failed := 0
+	for _, s := range samples {
+		start := time.Now()
+		text, err := transcribeSample(s, dt)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", s.name(), err)
+			failed++
+			continue
+		}
+		ms := time.Since(start).Milliseconds()
+		stored := s.info.Text
+		if s.info.Error != "" {
+			stored = "(failed: " + oneLine(s.info.Error, 60) + ")"
+		}
+		if s.info.Error != "" {
+			fmt.Fprintln(stdout, s.name()) // nothing stored to compare with
+		} else {
+			fmt.Fprintf(stdout, "%s  %.0f%% of words differ\n", s.name(), 100*wordDiff(s.info.Text, text))
+		}
+		fmt.Fprintf(stdout, "  %-32s %s\n", oneLine(s.info.Provider+"/"+s.info.Model, 32), stored)
+		fmt.Fprintf(stdout, "  %-32s %s  (%d ms)\n", oneLine(source, 32), text, ms)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d recordings failed", failed, len(samples))
+	}
+	return nil
+}
+
+// wordDiff is the word error rate of got against want, ignoring case and
+// punctuation: the word edits (substitutions, insertions, deletions) that
+// turn want into got, per word of want. 1 when want is empty and got isn't.
+func wordDiff(want, got string) float64 {
+	norm := func(s string) []string {
+		var words []string
+		for _, w := range strings.Fields(strings.ToLower(s)) {
+			if w = strings.TrimFunc(w, unicode.IsPunct); w != "" {
+				words = append(words, w)
+			}
+		}
+		return words
+	}
+	a, b := norm(want), norm(got)
+	if len(a) == 0 {
+		return float64(min(len(b), 1))
+	}
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range a {
+		cur[0] = i + 1
+		for j := range b {
+			sub := prev[j]
+			if a[i] != b[j] {
+				sub++
+			}
+			cur[j+1] = min(sub, prev[j+1]+1, cur[j]+1)
+		}
+		prev, cur = cur, prev
+	}
+	return float64(prev[len(b)]) / float64(len(a))
+}
+
+// exportSamples writes the recordings with a transcript to a zip in the
+// audiofolder layout dataset tools load as is: audio/<name>.<ext> and a
+// metadata.jsonl line per file. Failed recordings have no label and are
+// left out. Returns how many went in.
+func exportSamples(samples []sample, path string) (int, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	zw := zip.NewWriter(f)
+	var meta strings.Builder
+	n := 0
+	for _, s := range samples {
+		if s.info.Error != "" || strings.TrimSpace(s.info.Text) == "" {
+			continue
+		}
+		audio, err := s.audio()
+		if err != nil {
+			zw.Close()
+			f.Close()
+			return n, fmt.Errorf("%s: %w", s.name(), err)
+		}
+		name := "audio/" + s.name() + "." + s.info.Format
+		w, err := zw.Create(name)
+		if err == nil {
+			_, err = w.Write(audio)
+		}
+		if err != nil {
+			zw.Close()
+			f.Close()
+			return n, err
+		}
+		line, _ := json.Marshal(map[string]string{
+			"file_name":     name,
+			"transcription": s.info.Text,
+			"provider":      s.info.Provider,
+			"model":         s.info.Model,
+			"language":      s.info.Language,
+			"timestamp":     s.info.Timestamp,
+		})
+		meta.Write(line)
+		meta.WriteByte('\n')
+		n++
+	}
+	w, err := zw.Create("metadata.jsonl")
+	if err == nil {
+		_, err = io.WriteString(w, meta.String())
+	}
+	return n, errors.Join(err, zw.Close(), f.Close())
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"zee/archive"
+	"zee/config"
+	"zee/transcriber"
+)
+
+func writeSample(t *testing.T, name, info string) {
+	t.Helper()
+	dir := filepath.Join(config.Dir(), "samples", name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "audio.flac"), []byte("AUDIO "+name), 0644)
+	os.WriteFile(filepath.Join(dir, "info.json"), []byte(info), 0644)
+}
+
+func TestRunSamples(t *testing.T) {
+	prev := config.Dir()
+	config.SetDir(t.TempDir())
+	t.Cleanup(func() { config.SetDir(prev) })
+	os.WriteFile(config.SettingsPath(), []byte(`{"provider": "good"}`), 0644)
+	config.Load()
+	writeSample(t, "2026-10-01T09-00-00", `{"provider":"groq","model":"whisper","format":"flac","text":"Ship it on Friday."}`)
+	writeSample(t, "2026-10-02T09-00-00", `{"provider":"groq","model":"whisper","format":"flac","error":"dns lookup failed"}`)
+	writeSample(t, "2026-10-03T09-00-00", `{"provider":"groq","model":"whisper","format":"flac","error":"503"}`)
+
+	var copied string
+	env := samplesEnv{
+		resolve: func(name string) (transcriber.ProviderInfo, bool) {
+			var err error
+			if name == "down" {
+				err = errors.New("503")
+			}
+			return transcriber.ProviderInfo{
+				Name:      name,
+				Available: func() bool { return true },
+				New:       func() transcriber.Transcriber { return transcriber.NewFake("ship it friday", err) },
+			}, true
+		},
+		copy: func(s string) error { copied = s; return nil },
+	}
+	run := func(args ...string) (string, string, int) {
+		var out, errOut bytes.Buffer
+		code := runSamples(args, env, &out, &errOut)
+		return out.String(), errOut.String(), code
+	}
+
+	if out, _, code := run(); code != 0 || strings.Count(out, "\n") != 3 ||
+		!strings.Contains(out, "Ship it on Friday.") || !strings.Contains(out, "FAILED dns lookup failed") {
+		t.Errorf("list = %d, %q", code, out)
+	}
+	if out, _, _ := run("list", "-failed"); strings.Contains(out, "Friday") {
+		t.Errorf("list -failed = %q", out)
+	}
+
+	// retry takes the newest failure by default, every one with -all.
+	if out, _, code := run("retry"); code != 0 || !strings.HasPrefix(out, "2026-10-03T09-00-00  ship it friday") || copied != "ship it friday" {
+		t.Errorf("retry = %d, %q, copied %q", code, out, copied)
+	}
+	if out, _, code := run("retry", "-all"); code != 0 || strings.Count(out, "\n") != 2 || copied != "ship it friday\nship it friday" {
+		t.Errorf("retry -all = %d, %q, copied %q", code, out, copied)
+	}
+	if _, errOut, code := run("retry", "-provider", "down"); code != 1 || !strings.Contains(errOut, "failed again") {
+		t.Errorf("retry with a failing provider = %d, %q", code, errOut)
+	}
+
+	if out, _, code := run("retranscribe", "-provider", "other", "2026-10-01T09-00-00"); code != 0 ||
+		!strings.Contains(out, "25% of words differ") || !strings.Contains(out, "fake/") {
+		t.Errorf("retranscribe = %d, %q", code, out)
+	}
+	if out, _, code := run("retranscribe", "-provider", "other", "2026-10-02T09-00-00"); code != 0 ||
+		strings.Contains(out, "differ") || !strings.Contains(out, "(failed: dns lookup failed)") {
+		t.Errorf("retranscribe of a failed recording = %d, %q", code, out)
+	}
+	if _, _, code := run("retranscribe"); code != 2 {
+		t.Errorf("retranscribe without -provider = %d", code)
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "dataset.zip")
+	if out, _, code := run("export", "-o", zipPath); code != 0 || !strings.HasPrefix(out, "1 recordings") {
+		t.Fatalf("export = %d, %q", code, out)
+	}
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	f, _ := zr.Open("metadata.jsonl")
+	meta, _ := io.ReadAll(f)
+	if !strings.Contains(string(meta), `"file_name":"audio/2026-10-01T09-00-00.flac"`) ||
+		!strings.Contains(string(meta), `"transcription":"Ship it on Friday."`) {
+		t.Errorf("metadata.jsonl = %s", meta)
+	}
+}
+
+func TestSamplesFromSealedArchive(t *testing.T) {
+	prev := config.Dir()
+	config.SetDir(t.TempDir())
+	t.Cleanup(func() { config.SetDir(prev) })
+	os.WriteFile(config.SettingsPath(), []byte(`{"archive": {"enabled": true, "encrypt": true}}`), 0644)
+	config.Load()
+	config.SetAPIKey("archive", "correct horse")
+
+	a, err := archive.Open(filepath.Join(config.Dir(), "archive"), "correct horse", archive.Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Save(time.Date(2026, 10, 4, 9, 0, 0, 0, time.Local), map[string][]byte{
+		"audio.flac": []byte("AUDIO"),
+		"info.json":  []byte(`{"provider":"groq","model":"whisper","format":"flac","text":"Sealed away."}`),
+	})
+	var out, errOut bytes.Buffer
+	if code := runSamples([]string{"list", "-archive"}, samplesEnv{}, &out, &errOut); code != 0 || !strings.Contains(out.String(), "Sealed away.") {
+		t.Errorf("list -archive = %d, %q, %q", code, out.String(), errOut.String())
+	}
+}
+
+func TestArchivePassphraseFromEnv(t *testing.T) {
+	prev := config.Dir()
+	config.SetDir(t.TempDir())
+	t.Cleanup(func() { config.SetDir(prev) })
+	os.WriteFile(config.SettingsPath(), []byte(`{"archive": {"enabled": true, "encrypt": true}}`), 0644)
+	config.Load()
+	t.Setenv(archivePassphraseEnv, "")
+
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+	if _, err := openArchive(); err == nil {
+		t.Fatal("no passphrase anywhere: want an error, not a clear-text archive")
+	}
+	t.Setenv(archivePassphraseEnv, "correct horse")
+	a, err := openArchive()
+	if err != nil || !a.Encrypted() {
+		t.Fatalf("passphrase from $%s: %v", archivePassphraseEnv, err)
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	for _, c := range []struct {
+		want, got string
+		diff      float64
+	}{
+		{"Ship it on Friday.", "ship it, on friday", 0},
+		{"Ship it on Friday.", "ship it friday", 0.25},
+		{"a b", "c d e", 1.5},
+		{"", "", 0},
+		{"", "anything", 1},
+	} {
+		if got := wordDiff(c.want, c.got); got != c.diff {
+			t.Errorf("wordDiff(%q, %q) = %v, want %v", c.want, c.got, got, c.diff)
+		}
+	}
+}
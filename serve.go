@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zee/config"
+	"zee/log"
+	"zee/transcriber"
+)
+
+// `zee serve` puts the configured provider — above all an already-loaded
+// Parakeet/Whisper model — behind OpenAI's POST /v1/audio/transcriptions, so
+// editors, scripts and note apps on the machine reuse it instead of each
+// shipping their own. Any OpenAI client works by pointing its base URL here.
+//
+// Requests run concurrently; a local engine serializes them itself on its one
+// shared model (localProvider.inferMu), cloud providers take them in parallel.
+
+const serveDefaultAddr = "127.0.0.1:8765"
+
+// serveMaxUpload bounds a request body, which is held in memory while it is
+// transcribed. Past OpenAI's 25 MB — long files are what the local engines
+// and the chunker are for — but an hour of 16 kHz WAV, or many hours of MP3
+// or FLAC, not an unbounded recording. A var so tests can lower it.
+var serveMaxUpload int64 = 128 << 20
+
+// serveMaxField bounds a form field other than the file (a prompt, a language).
+const serveMaxField = 1 << 20
+
+type serveOptions struct {
+	addr  string
+	token string // "" = no auth
+}
+
+// runServe serves until ctx is done and returns the exit code.
+func runServe(ctx context.Context, opt serveOptions) int {
+	ln, err := net.Listen("tcp", opt.addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "zee serve: %v\n", err)
+		return 1
+	}
+	if opt.token == "" {
+		if host, _, _ := net.SplitHostPort(ln.Addr().String()); !net.ParseIP(host).IsLoopback() {
+			fmt.Fprintf(os.Stderr, "zee serve: warning: %s is reachable from other machines and -token is not set\n", host)
+		}
+	}
+	srv := &http.Server{Handler: newServeHandler(opt.token), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	fmt.Printf("serving %s/%s at http://%s/v1/audio/transcriptions\n",
+		activeTranscriber.Name(), activeTranscriber.GetModel(), ln.Addr())
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "zee serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func newServeHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/audio/transcriptions", serveTranscription)
+	mux.HandleFunc("GET /v1/models", serveModels)
+	if token == "" {
+		return mux
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			serveError(w, http.StatusUnauthorized, "invalid_api_key", "Incorrect API key provided.")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// serveError writes OpenAI's error body.
+func serveError(w http.ResponseWriter, status int, code, msg string) {
+	typ := "invalid_request_error"
+	if status >= 500 {
+		typ = "server_error"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"message": msg, "type": typ, "param": nil, "code": code},
+	})
+}
+
+func serveModels(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"object": "list",
+		"data": []map[string]any{{
+			"id":       activeTranscriber.GetModel(),
+			"object":   "model",
+			"owned_by": activeTranscriber.Name(),
+		}},
+	})
+}
+
+// serveTranscription handles OpenAI's multipart transcription request: file
+// (required), language, prompt and response_format are honoured; model,
+// temperature and the rest are accepted and ignored — the loaded model is the
+// model.
+func serveTranscription(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	r.Body = http.MaxBytesReader(w, r.Body, serveMaxUpload)
+	form, err := readTranscriptionForm(r)
+	if err != nil {
+		if tooBig := (*http.MaxBytesError)(nil); errors.As(err, &tooBig) {
+			serveError(w, http.StatusRequestEntityTooLarge, "file_too_large",
+				fmt.Sprintf("The upload is over zee serve's %d MB limit.", serveMaxUpload>>20))
+			return
+		}
+		serveError(w, http.StatusBadRequest, "invalid_request", "Could not parse multipart form: "+err.Error())
+		return
+	}
+	if form.data == nil {
+		serveError(w, http.StatusBadRequest, "missing_file", "The 'file' field is required.")
+		return
+	}
+	data := form.data
+
+	respFormat := cmp.Or(form.fields["response_format"], "json")
+	switch respFormat {
+	case "json", "text", "srt", "vtt", "verbose_json":
+	default:
+		serveError(w, http.StatusBadRequest, "unsupported_response_format",
+			fmt.Sprintf("response_format %q is not supported (json, text, srt, vtt, verbose_json)", respFormat))
+		return
+	}
+	format := sniffAudioFormat(form.filename, data)
+	if format == "" {
+		serveError(w, http.StatusBadRequest, "invalid_file_format",
+			"Unsupported file format: send wav, mp3 or flac.")
+		return
+	}
+	lang := form.fields["language"]
+	if lang == "" {
+		lang = activeTranscriber.GetLanguage()
+	}
+	hints := form.fields["prompt"]
+	if hints == "" {
+		hints = config.GetHints()
+	}
+
+	res, err := transcribeAudio(data, format, lang, hints)
+	if err != nil {
+		log.Warnf("serve: %s: %v", form.filename, err)
+		serveError(w, http.StatusInternalServerError, "transcription_failed", err.Error())
+		return
+	}
+	log.Info(fmt.Sprintf("serve: %s (%.1fs audio, %s) in %dms", form.filename, res.Duration, respFormat,
+		time.Since(start).Milliseconds()))
+
+	switch respFormat {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"text": strings.TrimSpace(res.Text)})
+	case "verbose_json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(verboseJSON(res, lang))
+	default:
+		body, err := formatTranscript(respFormat, res, form.filename)
+		if err != nil {
+			serveError(w, http.StatusInternalServerError, "format_failed", err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(body)
+	}
+}
+
+// transcriptionForm is a parsed transcription request.
+type transcriptionForm struct {
+	filename string
+	data     []byte // nil without a file part
+	fields   map[string]string
+}
+
+// readTranscriptionForm reads the multipart body part by part: the file is
+// read once, straight into memory, with no spill to a temp file and no second
+// copy; every other field is a short string.
+func readTranscriptionForm(r *http.Request) (*transcriptionForm, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	form := &transcriptionForm{fields: map[string]string{}}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" && form.data == nil {
+			form.filename = part.FileName()
+			form.data, err = io.ReadAll(part)
+			if form.data == nil {
+				form.data = []byte{}
+			}
+		} else {
+			var v []byte
+			v, err = io.ReadAll(io.LimitReader(part, serveMaxField))
+			form.fields[part.FormName()] = string(v)
+		}
+		part.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// verboseSegment is OpenAI's verbose_json segment. Seek and tokens zee has no
+// value for are zero/empty, as faster-whisper servers send them.
+type verboseSegment struct {
+	ID               int     `json:"id"`
+	Seek             int     `json:"seek"`
+	Start            float64 `json:"start"`
+	End              float64 `json:"end"`
+	Text             string  `json:"text"`
+	Tokens           []int   `json:"tokens"`
+	Temperature      float64 `json:"temperature"`
+	AvgLogProb       float64 `json:"avg_logprob"`
+	CompressionRatio float64 `json:"compression_ratio"`
+	NoSpeechProb     float64 `json:"no_speech_prob"`
+}
+
+type verboseTranscript struct {
+	Task     string           `json:"task"`
+	Language string           `json:"language"`
+	Duration float64          `json:"duration"`
+	Text     string           `json:"text"`
+	Segments []verboseSegment `json:"segments"`
+}
+
+func verboseJSON(res *transcriber.Result, lang string) verboseTranscript {
+	out := verboseTranscript{
+		Task:     "transcribe",
+		Language: lang,
+		Duration: res.Duration,
+		Text:     strings.TrimSpace(res.Text),
+		Segments: []verboseSegment{},
+	}
+	segs, _ := transcriptSegments(res) // no timeline at all: an empty list
+	for i, s := range segs {
+		out.Segments = append(out.Segments, verboseSegment{
+			ID:               i,
+			Start:            s.Start,
+			End:              s.End,
+			Text:             s.Text,
+			Tokens:           []int{},
+			Temperature:      s.Temperature,
+			AvgLogProb:       s.AvgLogProb,
+			CompressionRatio: s.CompressionRatio,
+			NoSpeechProb:     s.NoSpeechProb,
+		})
+	}
+	return out
+}
+
+// sniffAudioFormat names an upload's format from its extension, or failing
+// that its magic bytes (clients often send "blob" or "audio"). "" if it is
+// none of wav, mp3, flac.
+func sniffAudioFormat(name string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".wav":
+		return "wav"
+	case ".mp3":
+		return "mp3"
+	case ".flac":
+		return "flac"
+	}
+	switch {
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return "wav"
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return "flac"
+	case bytes.HasPrefix(data, []byte("ID3")), len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return "mp3"
+	}
+	return ""
+}
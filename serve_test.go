@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"zee/audio"
+	"zee/transcriber"
+)
+
+// transcriptionRequest builds OpenAI's multipart request body.
+func transcriptionRequest(t *testing.T, filename string, data []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if data != nil {
+		fw, _ := mw.CreateFormFile("file", filename)
+		fw.Write(data)
+	}
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	mw.Close()
+	req := httptest.NewRequest("POST", "/v1/audio/transcriptions", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestServeTranscriptionFormats(t *testing.T) {
+	orig := activeTranscriber
+	t.Cleanup(func() { activeTranscriber = orig })
+	activeTranscriber = transcriber.NewFake("hello world", nil)
+	wav := audio.PCMToWAV(make([]byte, 32000))
+	h := newServeHandler("")
+
+	cases := []struct {
+		format, wantType, want string
+	}{
+		{"", "application/json", `{"text":"hello world"}`},
+		{"text", "text/plain", "hello world\n"},
+		{"srt", "text/plain", "1\n00:00:00,000 --> 00:00:01,000\nhello world\n\n"},
+		{"verbose_json", "application/json", `"duration":1,"text":"hello world","segments":[{"id":0,"seek":0,"start":0,"end":1,`},
+	}
+	for _, c := range cases {
+		t.Run(cmp.Or(c.format, "default"), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			// "blob" is what browsers send: the format is sniffed from the bytes.
+			h.ServeHTTP(rec, transcriptionRequest(t, "blob", wav, map[string]string{"response_format": c.format, "model": "whisper-1"}))
+			if rec.Code != 200 {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, c.wantType) {
+				t.Errorf("Content-Type = %q, want %s", ct, c.wantType)
+			}
+			if !strings.Contains(rec.Body.String(), c.want) {
+				t.Errorf("body = %s\nwant it to contain %s", rec.Body.String(), c.want)
+			}
+		})
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	orig := activeTranscriber
+	t.Cleanup(func() { activeTranscriber = orig })
+	activeTranscriber = transcriber.NewFake("hello", nil)
+	wav := audio.PCMToWAV(make([]byte, 3200))
+
+	cases := []struct {
+		name   string
+		token  string
+		auth   string
+		req    *http.Request
+		status int
+		code   string
+	}{
+		{"no file", "", "", transcriptionRequest(t, "", nil, nil), 400, "missing_file"},
+		{"not audio", "", "", transcriptionRequest(t, "notes.txt", []byte("hello"), nil), 400, "invalid_file_format"},
+		{"bad format", "", "", transcriptionRequest(t, "a.wav", wav, map[string]string{"response_format": "xml"}), 400, "unsupported_response_format"},
+		{"no token", "s3cret", "", transcriptionRequest(t, "a.wav", wav, nil), 401, "invalid_api_key"},
+		{"wrong token", "s3cret", "Bearer nope", transcriptionRequest(t, "a.wav", wav, nil), 401, "invalid_api_key"},
+		{"right token", "s3cret", "Bearer s3cret", transcriptionRequest(t, "a.wav", wav, nil), 200, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.auth != "" {
+				c.req.Header.Set("Authorization", c.auth)
+			}
+			rec := httptest.NewRecorder()
+			newServeHandler(c.token).ServeHTTP(rec, c.req)
+			if rec.Code != c.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, c.status, rec.Body.String())
+			}
+			if c.code == "" {
+				return
+			}
+			var body struct {
+				Error struct{ Code, Type, Message string }
+			}
+			data, _ := io.ReadAll(rec.Body)
+			if err := json.Unmarshal(data, &body); err != nil || body.Error.Code != c.code || body.Error.Message == "" {
+				t.Errorf("error body = %s, want code %q", data, c.code)
+			}
+		})
+	}
+}
+
+func TestServeUploadLimit(t *testing.T) {
+	orig, origMax := activeTranscriber, serveMaxUpload
+	t.Cleanup(func() { activeTranscriber, serveMaxUpload = orig, origMax })
+	activeTranscriber = transcriber.NewFake("hello", nil)
+	serveMaxUpload = 64 << 10
+
+	rec := httptest.NewRecorder()
+	big := audio.PCMToWAV(make([]byte, 128<<10))
+	newServeHandler("").ServeHTTP(rec, transcriptionRequest(t, "a.wav", big, nil))
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "file_too_large") {
+		t.Errorf("oversized upload: status %d: %s", rec.Code, rec.Body.String())
+	}
+}
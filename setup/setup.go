@@ -129,7 +129,7 @@ func begin(cmd string) (code int, done bool) {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt)
 	go func() { <-sig; exitInterrupted() }()
-	transcriber.SetKeySource(config.APIKey)
+	WireProviders()
 	if err := config.Load(); err != nil {
 		fmt.Fprintf(os.Stderr, "warning: could not load settings: %v\n", err)
 	}
@@ -137,6 +137,17 @@ func begin(cmd string) (code int, done bool) {
 	return 0, false
 }
 
+// WireProviders points the providers at config: cloud API keys come from
+// credentials.json (via config.APIKey), never the environment, and the
+// self-hosted servers from config.json. The app and the wizard both call it
+// before any provider is used.
+func WireProviders() {
+	transcriber.SetKeySource(config.APIKey)
+	transcriber.SetServerSource(func(provider string) transcriber.Server {
+		return transcriber.Server{Addr: config.ServerAddr(provider), Model: config.ServerModel(provider)}
+	})
+}
+
 // Run executes the wizard and returns a process exit code (0 = mic granted,
 // the one hard requirement; 1 otherwise).
 func Run() int {
@@ -625,7 +636,7 @@ func stepProviders(r *results) {
 		tested[r.micProvider] = r.micTested
 	}
 	for _, p := range providers {
-		if !p.Local && config.HasAPIKey(p.Name) {
+		if !p.Local && !p.Keyless && config.HasAPIKey(p.Name) {
 			tested[p.Name] = testProvider(p, r.testPCM)
 		}
 	}
@@ -641,6 +652,10 @@ func stepProviders(r *results) {
 				label += " — offline, ready"
 			case p.Local:
 				label += " — offline, no key needed"
+			case p.Keyless && p.Available():
+				label += " — server configured"
+			case p.Keyless:
+				label += " — server not set"
 			case config.HasAPIKey(p.Name):
 				label += " — key set"
 			default:
@@ -667,6 +682,13 @@ func stepProviders(r *results) {
 			ensureModel(p, localDefaultModel(p))
 			continue
 		}
+		if p.Keyless {
+			fmt.Printf("  %s runs on your own server: set it under %q in %s.\n", p.Label, p.Name, config.SettingsPath())
+			if p.Name == "compatible" {
+				fmt.Printf("  If the server wants an API key, add it as %q in %s.\n", p.Name, config.CredentialsPath())
+			}
+			continue
+		}
 		changed, backedOut := promptAPIKey(p)
 		if backedOut {
 			continue
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"zee/audio"
+	"zee/config"
+	"zee/transcriber"
+)
+
+// `zee transcribe -` reads audio from stdin, so `arecord … | zee transcribe -`
+// and `ffmpeg … -f wav - | zee transcribe -` need no temp file. The input is a
+// WAV stream, or headerless s16le mono PCM when -raw-rate gives its rate.
+//
+// With a streaming model the audio is fed to a live session as it arrives and
+// (for text output) the transcript is written to stdout as the provider
+// produces it — a pipeline sees words while the recorder is still running.
+// Otherwise the whole stream is read first and goes through the -transcribe
+// path, chunking included.
+
+type stdinOptions struct {
+	rawRate int    // 0 = the input is a WAV stream
+	format  string // an outputFormats key
+	stream  bool   // the active model streams
+}
+
+// runStdin transcribes in to out and returns the exit code; errors go to
+// errOut.
+func runStdin(in io.Reader, out, errOut io.Writer, opt stdinOptions) int {
+	var (
+		src *audio.PCMStream
+		err error
+	)
+	if opt.rawRate > 0 {
+		src, err = audio.NewRawStream(in, opt.rawRate, 1)
+	} else {
+		src, err = audio.NewWAVStream(in)
+	}
+	if err != nil {
+		fmt.Fprintf(errOut, "zee transcribe -: %v (raw PCM needs -raw-rate)\n", err)
+		return 1
+	}
+
+	var res *transcriber.Result
+	if opt.stream {
+		res, err = streamStdin(src, out, opt.format == "text")
+	} else {
+		res, err = batchStdin(src)
+	}
+	if err != nil {
+		fmt.Fprintf(errOut, "zee transcribe -: %v\n", err)
+		return 1
+	}
+	if opt.stream && opt.format == "text" {
+		return 0 // already written as it arrived
+	}
+	data, err := formatTranscript(opt.format, res, "")
+	if err != nil {
+		fmt.Fprintf(errOut, "zee transcribe -: %v\n", err)
+		return 1
+	}
+	out.Write(data)
+	return 0
+}
+
+// batchStdin reads the stream to its end and transcribes it as one file.
+func batchStdin(src *audio.PCMStream) (*transcriber.Result, error) {
+	var pcm []byte
+	for {
+		block, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		pcm = append(pcm, block...)
+	}
+	if len(pcm) == 0 {
+		return nil, fmt.Errorf("no audio on stdin")
+	}
+	return transcribeAudio(audio.PCMToWAV(pcm), "wav", activeTranscriber.GetLanguage(), config.GetHints())
+}
+
+// streamStdin feeds the stream to a live session block by block. With live
+// set, the transcript goes to out as the updates extend it, and the rest of
+// the final text (plus a newline) once the session closes.
+func streamStdin(src *audio.PCMStream, out io.Writer, live bool) (*transcriber.Result, error) {
+	sess, err := activeTranscriber.NewSession(context.Background(), transcriber.SessionConfig{
+		Stream:   true,
+		Language: activeTranscriber.GetLanguage(),
+		Hints:    config.GetHints(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var printed string
+	updatesDone := make(chan struct{})
+	go func() {
+		defer close(updatesDone)
+		for text := range sess.Updates() {
+			if live && len(text) > len(printed) {
+				fmt.Fprint(out, text[len(printed):])
+				printed = text
+			}
+		}
+	}()
+
+	var fed int
+	var readErr error
+	for {
+		block, err := src.Next()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				readErr = err
+			}
+			break
+		}
+		sess.Feed(block)
+		fed += len(block)
+	}
+	sr, err := sess.Close()
+	<-updatesDone
+	if err = errors.Join(readErr, err); err != nil {
+		if live && printed != "" {
+			fmt.Fprintln(out)
+		}
+		return nil, err
+	}
+	if live {
+		if strings.HasPrefix(sr.Text, printed) {
+			fmt.Fprint(out, sr.Text[len(printed):])
+		}
+		fmt.Fprintln(out)
+	}
+	return &transcriber.Result{Text: sr.Text, Duration: pcmSeconds(fed)}, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"zee/audio"
+	"zee/transcriber"
+)
+
+func TestRunStdin(t *testing.T) {
+	orig := activeTranscriber
+	t.Cleanup(func() { activeTranscriber = orig })
+	second := make([]byte, 32000)
+
+	cases := []struct {
+		name   string
+		stream bool
+		input  []byte
+		opt    stdinOptions
+		want   string
+	}{
+		{"wav batch", false, audio.PCMToWAV(second), stdinOptions{format: "text"}, "hello\n"},
+		{"raw batch srt", false, second, stdinOptions{rawRate: 16000, format: "srt"},
+			"1\n00:00:00,000 --> 00:00:01,000\nhello\n\n"},
+		{"wav stream", true, audio.PCMToWAV(second), stdinOptions{format: "text", stream: true}, "hello\n"},
+		{"raw stream json", true, second, stdinOptions{rawRate: 16000, format: "json", stream: true}, `"duration": 1,`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.stream {
+				t.Setenv("ZEE_FAKE_STREAM", "1")
+			}
+			activeTranscriber = transcriber.NewFake("hello", nil)
+			var out, errOut bytes.Buffer
+			if code := runStdin(bytes.NewReader(c.input), &out, &errOut, c.opt); code != 0 {
+				t.Fatalf("exit %d: %s", code, errOut.String())
+			}
+			if !strings.Contains(out.String(), c.want) {
+				t.Errorf("output %q, want %q", out.String(), c.want)
+			}
+		})
+	}
+}
+
+func TestRunStdinRejectsRawWithoutRate(t *testing.T) {
+	var out, errOut bytes.Buffer
+	if code := runStdin(bytes.NewReader(make([]byte, 3200)), &out, &errOut, stdinOptions{format: "text"}); code != 1 {
+		t.Errorf("exit %d, want 1", code)
+	}
+	if !strings.Contains(errOut.String(), "-raw-rate") {
+		t.Errorf("error %q should point at -raw-rate", errOut.String())
+	}
+}
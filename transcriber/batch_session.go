@@ -11,9 +11,15 @@ import (
 
 type transcribeFunc func(audio []byte, format, lang, hints string) (*Result, error)
 
+// partialTranscribeFunc is a transcribeFunc for a provider that returns the
+// transcript incrementally: partial receives the text so far (cumulative, like
+// every Session update) as each piece arrives.
+type partialTranscribeFunc func(audio []byte, format, lang, hints string, partial func(text string)) (*Result, error)
+
 type batchSession struct {
 	cfg        SessionConfig
 	transcribe transcribeFunc
+	partial    partialTranscribeFunc // set instead of transcribe by newPartialBatchSession
 	encoder    encoder.Encoder
 	updates    chan string
 	blockChan  chan []int16
@@ -50,6 +56,19 @@ func newBatchSession(cfg SessionConfig, transcribe transcribeFunc) (*batchSessio
 	return bs, nil
 }
 
+// newPartialBatchSession is a batch session — the whole recording is encoded
+// and uploaded on Close — whose response streams back: each partial goes out on
+// Updates() while Close is still reading, so auto-paste starts on the first
+// words instead of after the last.
+func newPartialBatchSession(cfg SessionConfig, transcribe partialTranscribeFunc) (*batchSession, error) {
+	bs, err := newBatchSession(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	bs.partial = transcribe
+	return bs, nil
+}
+
 func (bs *batchSession) Feed(pcm []byte) {
 	bs.bufMu.Lock()
 	for i := 0; i+1 < len(pcm); i += 2 {
@@ -85,18 +104,40 @@ func (bs *batchSession) Close() (SessionResult, error) {
 
 	close(bs.blockChan)
 	<-bs.encodeDone
-	close(bs.updates)
+	if bs.partial == nil {
+		close(bs.updates)
+	}
 
 	if err := bs.encoder.Close(); err != nil {
+		if bs.partial != nil {
+			close(bs.updates)
+		}
 		return SessionResult{}, err
 	}
 
 	audioData := bs.encoder.Bytes()
 	apiFormat := apiFormatFromConfig(bs.cfg.Format)
 
-	result, err := bs.transcribe(audioData, apiFormat, bs.cfg.Language, bs.cfg.Hints)
-	if err != nil {
-		return SessionResult{AudioData: audioData, AudioFormat: apiFormat}, err
+	var result *Result
+	var err error
+	if bs.partial != nil {
+		var sent string
+		result, err = bs.partial(audioData, apiFormat, bs.cfg.Language, bs.cfg.Hints, func(text string) {
+			// A leading space would be pasted as-is; anything after it is stable.
+			sent = strings.TrimLeft(text, " \t\n")
+			bs.updates <- sent
+		})
+		close(bs.updates)
+		if err != nil {
+			// Keep what was already delivered: it has been pasted, and the
+			// fallback path must not transcribe (and paste) it again.
+			return SessionResult{Text: strings.TrimSpace(sent), AudioData: audioData, AudioFormat: apiFormat}, err
+		}
+	} else {
+		result, err = bs.transcribe(audioData, apiFormat, bs.cfg.Language, bs.cfg.Hints)
+		if err != nil {
+			return SessionResult{AudioData: audioData, AudioFormat: apiFormat}, err
+		}
 	}
 
 	text := strings.TrimSpace(result.Text)
@@ -130,6 +171,8 @@ func (bs *batchSession) Close() (SessionResult, error) {
 			TLSProtocol:      netMetrics.TLSProtocol,
 			Confidence:       result.Confidence,
 			InferenceMs:      result.InferenceMs,
+			Retries:          len(netMetrics.Retries),
+			RetryMs:          float64(netMetrics.RetryCost().Milliseconds()),
 		},
 		Metrics: bs.formatMetrics(rawSize, encodedSize, compressionPct, audioDuration, result),
 	}
@@ -160,6 +203,9 @@ func (bs *batchSession) formatMetrics(rawSize, encodedSize uint64, compressionPc
 		fmt.Sprintf("download:   %dms", metrics.Download.Milliseconds()),
 		fmt.Sprintf("total:      %dms", metrics.Sum().Milliseconds()),
 	}
+	if n := len(metrics.Retries); n > 0 {
+		lines = append(lines, fmt.Sprintf("retries:    %d (+%dms)", n, metrics.RetryCost().Milliseconds()))
+	}
 	if result.Duration > 0 {
 		lines = append(lines, fmt.Sprintf("api_dur:    %.2fs", result.Duration))
 	}
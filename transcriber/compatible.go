@@ -0,0 +1,190 @@
+package transcriber
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Compatible speaks OpenAI's /v1/audio/transcriptions multipart protocol
+// against a user-supplied server — LocalAI, a self-hosted faster-whisper
+// server, vLLM, an internal gateway. Only the endpoint and the model name come
+// from the user; the wire format is the one the cloud providers already use.
+
+// DefaultCompatibleModel is sent when the user configured a base URL but no
+// model: most OpenAI-compatible servers alias "whisper-1" to whatever they run.
+const DefaultCompatibleModel = "whisper-1"
+
+// CompatibleEndpoint is the user-configured target of the "compatible"
+// provider. BaseURL is what precedes /audio/transcriptions, the same way the
+// OpenAI SDKs take base_url ("http://localhost:8000/v1"); a full
+// .../audio/transcriptions URL is accepted as-is.
+type CompatibleEndpoint struct {
+	BaseURL string
+	Model   string
+}
+
+// compatibleEndpoint is the configured endpoint, from the server source like
+// the other self-hosted providers'.
+func compatibleEndpoint() CompatibleEndpoint {
+	s := serverSource("compatible")
+	return CompatibleEndpoint{BaseURL: s.Addr, Model: s.Model}
+}
+
+// transcriptionsURL turns a base URL into the transcription endpoint.
+func (e CompatibleEndpoint) transcriptionsURL() string {
+	u := strings.TrimRight(strings.TrimSpace(e.BaseURL), "/")
+	if strings.HasSuffix(u, "/audio/transcriptions") {
+		return u
+	}
+	return u + "/audio/transcriptions"
+}
+
+func (e CompatibleEndpoint) model() string {
+	if m := strings.TrimSpace(e.Model); m != "" {
+		return m
+	}
+	return DefaultCompatibleModel
+}
+
+type Compatible struct {
+	baseTranscriber
+	apiKey    string
+	plainJSON atomic.Bool // the server rejected verbose_json once
+}
+
+func NewCompatible(ep CompatibleEndpoint, apiKey string) *Compatible {
+	apiURL := ep.transcriptionsURL()
+	return &Compatible{
+		baseTranscriber: baseTranscriber{
+			client: NewTracedClient(apiURL),
+			apiURL: apiURL,
+			model:  ep.model(),
+		},
+		apiKey: apiKey,
+	}
+}
+
+// compatibleModels is the one-entry model list: the server decides what the
+// name means, so there is nothing to enumerate. Languages are whisper's, the
+// engine nearly every such server runs.
+func compatibleModels(ep CompatibleEndpoint) []ModelInfo {
+	m := ep.model()
+	return []ModelInfo{{ID: m, Label: m, Stream: false, Languages: whisperLangs}}
+}
+
+func (c *Compatible) SupportedLanguages() []Language { return whisperLangs }
+func (c *Compatible) Name() string                   { return "compatible" }
+func (c *Compatible) Models() []ModelInfo {
+	return []ModelInfo{{ID: c.GetModel(), Label: c.GetModel(), Stream: false, Languages: whisperLangs}}
+}
+
+func (c *Compatible) NewSession(_ context.Context, cfg SessionConfig) (Session, error) {
+	go c.client.Warm()
+	if cfg.Stream {
+		return nil, fmt.Errorf("compatible provider does not support streaming transcription")
+	}
+	return newBatchSession(cfg, c.Transcribe)
+}
+
+// compatibleProvider is a self-hosted server like vosk's: gated on its base
+// URL, not a key. A key, if the server wants one, is optional.
+func compatibleProvider() ProviderInfo {
+	const name = "compatible"
+	configured := func() bool { return strings.TrimSpace(compatibleEndpoint().BaseURL) != "" }
+	return ProviderInfo{
+		Name:      name,
+		Label:     "OpenAI-compatible",
+		Models:    compatibleModels(compatibleEndpoint()),
+		Keyless:   true,
+		Available: configured,
+		New:       func() Transcriber { return NewCompatible(compatibleEndpoint(), keySource(name)) },
+		Status:    func(string) ModelStatus { return ModelStatus{Ready: configured()} },
+	}
+}
+
+func (c *Compatible) Transcribe(audioData []byte, format, lang, hints string) (*Result, error) {
+	// verbose_json carries the segment timestamps subtitles are cut from; a
+	// server that only implements plain json rejects it, and is asked again
+	// in json (and only in json from then on) rather than failing. A 400 can
+	// as well be a bad upload or language, so the downgrade sticks only when
+	// the error names the format or the json request gets through.
+	verbose := !c.plainJSON.Load()
+	resp, err := c.post(audioData, format, lang, hints, verbose)
+	if err == nil && verbose && (resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity) {
+		body := strings.ToLower(string(resp.Body))
+		named := strings.Contains(body, "response_format") || strings.Contains(body, "verbose_json") ||
+			strings.Contains(body, "timestamp_granularities")
+		plain, perr := c.post(audioData, format, lang, hints, false)
+		switch {
+		case perr == nil && plain.StatusCode == 200, named:
+			c.plainJSON.Store(true)
+			resp, err = plain, perr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("compatible API error %d: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	var cResp groqResponse
+	if err := json.Unmarshal(resp.Body, &cResp); err != nil {
+		return nil, fmt.Errorf("compatible response parse error: %w", err)
+	}
+
+	res := cResp.result()
+	res.Metrics = resp.Metrics
+	if rl := firstNonEmpty(resp.Header, "x-ratelimit-remaining-requests"); rl != "?" {
+		res.RateLimit = rl + "/" + firstNonEmpty(resp.Header, "x-ratelimit-limit-requests")
+	}
+	return res, nil
+}
+
+func (c *Compatible) post(audioData []byte, format, lang, hints string, verbose bool) (*TracedResponse, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", "audio."+format)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(audioData); err != nil {
+		return nil, err
+	}
+
+	writer.WriteField("model", c.GetModel())
+	if verbose {
+		writer.WriteField("response_format", "verbose_json")
+		writer.WriteField("timestamp_granularities[]", "segment")
+	} else {
+		writer.WriteField("response_format", "json")
+	}
+	if lang != "" {
+		writer.WriteField("language", lang)
+	}
+	if hints != "" {
+		writer.WriteField("prompt", plainHints(hints))
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", c.apiURL, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return c.client.Do(req)
+}
@@ -0,0 +1,162 @@
+package transcriber
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompatibleTranscriptionsURL(t *testing.T) {
+	for _, tt := range []struct{ base, want string }{
+		{"http://localhost:8000/v1", "http://localhost:8000/v1/audio/transcriptions"},
+		{"http://localhost:8000/v1/", "http://localhost:8000/v1/audio/transcriptions"},
+		{"https://gw.internal/v1/audio/transcriptions", "https://gw.internal/v1/audio/transcriptions"},
+		{" http://box:9000/openai/v1 ", "http://box:9000/openai/v1/audio/transcriptions"},
+	} {
+		if got := (CompatibleEndpoint{BaseURL: tt.base}).transcriptionsURL(); got != tt.want {
+			t.Errorf("transcriptionsURL(%q) = %q, want %q", tt.base, got, tt.want)
+		}
+	}
+}
+
+// TestCompatibleGatedOnBaseURL: the key is optional, the endpoint is not — a
+// provider with nowhere to send audio must not be offered as available.
+func TestCompatibleGatedOnBaseURL(t *testing.T) {
+	t.Cleanup(func() { SetServerSource(func(string) Server { return Server{} }) })
+
+	SetServerSource(func(string) Server { return Server{} })
+	if providerNamed(t, "compatible").Available() {
+		t.Error("compatible should be unavailable with no base URL")
+	}
+
+	SetServerSource(func(p string) Server {
+		if p == "compatible" {
+			return Server{Addr: "http://localhost:8000/v1"}
+		}
+		return Server{}
+	})
+	p := providerNamed(t, "compatible")
+	if !p.Available() || !p.Keyless || !p.Status(DefaultCompatibleModel).Ready {
+		t.Error("compatible should be available once a base URL is configured, even without a key")
+	}
+	if len(p.Models) != 1 || p.Models[0].ID != DefaultCompatibleModel {
+		t.Errorf("Models = %+v, want the single default model %q", p.Models, DefaultCompatibleModel)
+	}
+}
+
+// TestCompatibleTranscribe drives a full request against a stand-in server:
+// the multipart fields must match OpenAI's protocol, the optional key must be
+// sent as a bearer token, and the verbose_json segments must be parsed.
+func TestCompatibleTranscribe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			http.Error(w, "wrong path "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-local" {
+			http.Error(w, "bad auth "+got, http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for field, want := range map[string]string{
+			"model": "Systran/faster-whisper-small", "language": "de", "prompt": "Zee", "response_format": "verbose_json",
+			"timestamp_granularities[]": "segment",
+		} {
+			if got := r.FormValue(field); got != want {
+				http.Error(w, field+"="+got, http.StatusBadRequest)
+				return
+			}
+		}
+		f, hdr, err := r.FormFile("file")
+		if err != nil || hdr.Filename != "audio.flac" {
+			http.Error(w, "missing file", http.StatusBadRequest)
+			return
+		}
+		if b, _ := io.ReadAll(f); string(b) != "AUDIO" {
+			http.Error(w, "wrong audio", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"text":"hallo welt","duration":1.5,"segments":[{"text":"hallo welt","start":0,"end":1.5,"no_speech_prob":0.1,"avg_logprob":-0.2}]}`)
+	}))
+	defer srv.Close()
+
+	c := NewCompatible(CompatibleEndpoint{BaseURL: srv.URL + "/v1", Model: "Systran/faster-whisper-small"}, "sk-local")
+	res, err := c.Transcribe([]byte("AUDIO"), "flac", "de", "Zee")
+	if err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+	if res.Text != "hallo welt" || res.Duration != 1.5 {
+		t.Errorf("result = %q / %.1fs, want %q / 1.5s", res.Text, res.Duration, "hallo welt")
+	}
+	if len(res.Segments) != 1 || res.Segments[0].End != 1.5 || res.NoSpeechProb != 0.1 {
+		t.Errorf("segments not parsed: %+v", res.Segments)
+	}
+	if res.Metrics == nil {
+		t.Error("Metrics should be set from the traced request")
+	}
+}
+
+// TestCompatibleFallsBackToJSON: a server without verbose_json still
+// transcribes, and isn't asked for it again.
+func TestCompatibleFallsBackToJSON(t *testing.T) {
+	var formats []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			return // a connection warm-up
+		}
+		r.ParseMultipartForm(1 << 20)
+		f := r.FormValue("response_format")
+		formats = append(formats, f)
+		if f != "json" {
+			http.Error(w, `{"error":"unsupported response_format"}`, http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"text":"hello"}`)
+	}))
+	defer srv.Close()
+
+	c := NewCompatible(CompatibleEndpoint{BaseURL: srv.URL}, "")
+	for range 2 {
+		if res, err := c.Transcribe([]byte("AUDIO"), "flac", "", ""); err != nil || res.Text != "hello" {
+			t.Fatalf("Transcribe = %+v, %v", res, err)
+		}
+	}
+	if got := strings.Join(formats, ","); got != "verbose_json,json,json" {
+		t.Errorf("response_format sent = %s", got)
+	}
+}
+
+// TestCompatibleKeepsVerboseOnOtherErrors: a 400 that isn't about the format
+// fails the request as it was, and the next one asks for verbose_json again.
+func TestCompatibleKeepsVerboseOnOtherErrors(t *testing.T) {
+	var formats []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			return
+		}
+		r.ParseMultipartForm(1 << 20)
+		f := r.FormValue("response_format")
+		formats = append(formats, f)
+		msg := `{"error":"audio file could not be decoded"}`
+		if f == "json" {
+			msg = `{"error":"still bad"}`
+		}
+		http.Error(w, msg, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c := NewCompatible(CompatibleEndpoint{BaseURL: srv.URL}, "")
+	for range 2 {
+		if _, err := c.Transcribe([]byte("AUDIO"), "flac", "", ""); err == nil || !strings.Contains(err.Error(), "could not be decoded") {
+			t.Fatalf("Transcribe error = %v, want the verbose request's", err)
+		}
+	}
+	if got := strings.Join(formats, ","); got != "verbose_json,json,verbose_json,json" {
+		t.Errorf("response_format sent = %s", got)
+	}
+}
@@ -2,13 +2,17 @@ package transcriber
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"zee/encoder"
+	"zee/log"
 )
 
 type Deepgram struct {
@@ -66,7 +70,52 @@ func (d *Deepgram) newStreamSession(ctx context.Context, lang, hints string) (Se
 			Hints:      hints,
 		})
 	}
-	return newStreamSession(dial), nil
+	return newStreamSession("deepgram", dial), nil
+}
+
+// deepgramMaxBiasQuery caps the encoded size of the biasing parameters.
+// Deepgram rejects over-long request lines, and the WebSocket handshake
+// carries the same query, so a long hints.txt would fail every dictation
+// rather than just lose its tail.
+const deepgramMaxBiasQuery = 4000
+
+// addDeepgramBias adds hints to q as the model's biasing parameter: keyterm for
+// nova-3, keywords=term:boost for the older models. Keyterm prompting takes no
+// boost, so there the weights only decide which terms go first; either way the
+// heaviest terms are the ones kept when the cap cuts the list. The terms sent
+// are logged, and so is anything dropped.
+func addDeepgramBias(q url.Values, model, hints string) {
+	terms := parseHints(hints)
+	if len(terms) == 0 {
+		return
+	}
+	slices.SortStableFunc(terms, func(a, b hintTerm) int { return cmp.Compare(b.Weight, a.Weight) })
+
+	param := "keywords"
+	if strings.HasPrefix(model, "nova-3") {
+		param = "keyterm"
+	}
+	var sent []string
+	size := 0
+	for _, t := range terms {
+		v := t.Term
+		if param == "keywords" && t.Weight != 0 {
+			v += ":" + strconv.FormatFloat(t.Weight, 'f', -1, 64)
+		}
+		n := len(param) + len("=&") + len(url.QueryEscape(v))
+		if size+n > deepgramMaxBiasQuery {
+			break
+		}
+		size += n
+		q.Add(param, v)
+		sent = append(sent, v)
+	}
+	msg := fmt.Sprintf("deepgram_bias model=%s param=%s sent=%d terms=%q", model, param, len(sent), strings.Join(sent, ", "))
+	if dropped := len(terms) - len(sent); dropped > 0 {
+		log.Warn(fmt.Sprintf("%s dropped=%d (over the %d-byte query cap)", msg, dropped, deepgramMaxBiasQuery))
+		return
+	}
+	log.Info(msg)
 }
 
 type deepgramResponse struct {
@@ -97,9 +146,7 @@ func (d *Deepgram) Transcribe(audioData []byte, format, lang, hints string) (*Re
 			return nil, err
 		}
 		q := u.Query()
-		for _, term := range strings.Split(hints, ",") {
-			q.Add("keyterm", strings.TrimSpace(term))
-		}
+		addDeepgramBias(q, q.Get("model"), hints)
 		u.RawQuery = q.Encode()
 		apiURL = u.String()
 	}
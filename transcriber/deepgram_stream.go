@@ -68,15 +68,24 @@ func (d *Deepgram) startStream(ctx context.Context, cfg streamSessionConfig) (ra
 		q.Set("language", "multi")
 	}
 	if cfg.Hints != "" {
-		for _, term := range strings.Split(cfg.Hints, ",") {
-			q.Add("keyterm", strings.TrimSpace(term))
-		}
+		addDeepgramBias(q, model, cfg.Hints)
 	}
 	endpoint.RawQuery = q.Encode()
 
 	headers := http.Header{}
 	headers.Set("Authorization", "Token "+d.apiKey)
 
+	conn, streamCtx, cancel, err := dialStream(ctx, "deepgram", endpoint.String(), headers)
+	if err != nil {
+		return nil, err
+	}
+	return &deepgramStreamSession{conn: conn, ctx: streamCtx, cancel: cancel}, nil
+}
+
+// dialStream opens a provider's streaming WebSocket. It returns the context
+// the connection lives on and its cancel, which the rawStreamSession's Close
+// must call.
+func dialStream(ctx context.Context, provider, endpoint string, headers http.Header) (*websocket.Conn, context.Context, context.CancelFunc, error) {
 	streamCtx, cancel := context.WithCancel(ctx)
 	// Bound the handshake so a dead network fails in seconds — but WITHOUT a
 	// context deadline: the dial ctx stays attached to the upgraded connection,
@@ -89,17 +98,16 @@ func (d *Deepgram) startStream(ctx context.Context, cfg streamSessionConfig) (ra
 	}
 	dialCh := make(chan dialResult, 1)
 	go func() {
-		c, _, err := websocket.Dial(streamCtx, endpoint.String(), &websocket.DialOptions{HTTPHeader: headers})
+		c, _, err := websocket.Dial(streamCtx, endpoint, &websocket.DialOptions{HTTPHeader: headers})
 		dialCh <- dialResult{c, err}
 	}()
-	var conn *websocket.Conn
 	select {
 	case r := <-dialCh:
 		if r.err != nil {
 			cancel()
-			return nil, r.err
+			return nil, nil, nil, r.err
 		}
-		conn = r.conn
+		return r.conn, streamCtx, cancel, nil
 	case <-time.After(15 * time.Second):
 		cancel()
 		// cancel() aborts an in-flight dial, but one that *just* succeeded is
@@ -110,10 +118,8 @@ func (d *Deepgram) startStream(ctx context.Context, cfg streamSessionConfig) (ra
 				r.conn.Close(websocket.StatusGoingAway, "dial timed out")
 			}
 		}()
-		return nil, fmt.Errorf("deepgram: connect timed out after 15s")
+		return nil, nil, nil, fmt.Errorf("%s: connect timed out after 15s", provider)
 	}
-
-	return &deepgramStreamSession{conn: conn, ctx: streamCtx, cancel: cancel}, nil
 }
 
 func (s *deepgramStreamSession) Send(pcm []byte) error {
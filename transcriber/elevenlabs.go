@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"mime/multipart"
 	"net/http"
-	"strings"
+
+	"zee/encoder"
 )
 
-const ModelScribeV2 = "scribe_v2"
+const (
+	ModelScribeV2         = "scribe_v2"
+	ModelScribeV2Realtime = "scribe_v2_realtime"
+)
 
 var scribeV2Langs = langsFromCodes([]string{
 	"af", "am", "ar", "hy", "as", "az", "be", "bn", "bs", "bg",
@@ -26,11 +30,13 @@ var scribeV2Langs = langsFromCodes([]string{
 
 var ElevenLabsModels = []ModelInfo{
 	{ID: ModelScribeV2, Label: "Scribe V2", Stream: false, Languages: scribeV2Langs},
+	{ID: ModelScribeV2Realtime, Label: "Scribe V2 Realtime (stream)", Stream: true, Languages: scribeV2Langs},
 }
 
 type ElevenLabs struct {
 	baseTranscriber
-	apiKey string
+	apiKey    string
+	streamURL string
 }
 
 func NewElevenLabs(apiKey string) *ElevenLabs {
@@ -41,7 +47,8 @@ func NewElevenLabs(apiKey string) *ElevenLabs {
 			apiURL: apiURL,
 			model:  ModelScribeV2,
 		},
-		apiKey: apiKey,
+		apiKey:    apiKey,
+		streamURL: elevenLabsRealtimeURL,
 	}
 }
 
@@ -51,14 +58,28 @@ func (e *ElevenLabs) SupportedLanguages() []Language {
 func (e *ElevenLabs) Name() string          { return "elevenlabs" }
 func (e *ElevenLabs) Models() []ModelInfo    { return ElevenLabsModels }
 
-func (e *ElevenLabs) NewSession(_ context.Context, cfg SessionConfig) (Session, error) {
+func (e *ElevenLabs) NewSession(ctx context.Context, cfg SessionConfig) (Session, error) {
 	go e.client.Warm()
 	if cfg.Stream {
-		return nil, fmt.Errorf("elevenlabs does not support streaming transcription")
+		return e.newStreamSession(ctx, cfg.Language)
 	}
 	return newBatchSession(cfg, e.Transcribe)
 }
 
+// newStreamSession: the realtime endpoint takes no keyterms, so hints only
+// reach the batch model.
+func (e *ElevenLabs) newStreamSession(ctx context.Context, lang string) (Session, error) {
+	dial := func() (rawStreamSession, error) {
+		return e.startStream(ctx, streamSessionConfig{
+			SampleRate: encoder.SampleRate,
+			Channels:   encoder.Channels,
+			Language:   lang,
+			Model:      ModelScribeV2Realtime,
+		})
+	}
+	return newStreamSession("elevenlabs", dial), nil
+}
+
 type elevenLabsResponse struct {
 	Text               string  `json:"text"`
 	LanguageCode       string  `json:"language_code"`
@@ -84,14 +105,20 @@ func (e *ElevenLabs) Transcribe(audioData []byte, format, lang, hints string) (*
 		return nil, err
 	}
 
-	writer.WriteField("model_id", e.GetModel())
+	model := e.GetModel()
+	if model == ModelScribeV2Realtime {
+		// The realtime model only exists on the WebSocket; a file (or a
+		// fallback re-submit) goes to its batch sibling.
+		model = ModelScribeV2
+	}
+	writer.WriteField("model_id", model)
 	if lang != "" {
 		writer.WriteField("language_code", lang)
 	}
 	writer.WriteField("tag_audio_events", "false")
 	if hints != "" {
-		for _, word := range strings.Split(hints, ",") {
-			writer.WriteField("keyterms[]", strings.TrimSpace(word))
+		for _, t := range parseHints(hints) {
+			writer.WriteField("keyterms[]", t.Term)
 		}
 	}
 	writer.Close()
@@ -0,0 +1,166 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"nhooyr.io/websocket"
+)
+
+// ElevenLabs realtime Scribe: PCM goes up as base64 JSON chunks, transcripts
+// come back as partial_transcript (interim) and committed_transcript (final)
+// messages. Every commit is ours (commit_strategy=manual): one at each pause
+// in the audio, the way Deepgram sends is_final, and one at the end of the
+// dictation. Each commit gets one committed_transcript, in order, so counting
+// them finds the reply to the last — the tail streamSession waits for. With
+// the server's VAD committing too, a pause commit arriving after CloseSend
+// was indistinguishable from it.
+
+const (
+	// elevenLabsPauseMs of quiet after speech commits what was said.
+	elevenLabsPauseMs = 600
+	// elevenLabsQuietRMS is the s16 RMS below which a chunk counts as quiet
+	// (about -40 dBFS).
+	elevenLabsQuietRMS = 330
+)
+
+const elevenLabsRealtimeURL = "wss://api.elevenlabs.io/v1/speech-to-text/realtime"
+
+type elevenLabsStreamMessage struct {
+	MessageType string `json:"message_type"`
+	Text        string `json:"text"`
+	Error       string `json:"error"`
+}
+
+type elevenLabsStreamSession struct {
+	conn       *websocket.Conn
+	ctx        context.Context
+	cancel     context.CancelFunc
+	sampleRate int
+
+	// Pause detection, on the sending side only.
+	spoke   bool // speech since the last commit
+	quietMs int  // quiet since the last speech
+
+	sent     atomic.Int32 // commits sent
+	received atomic.Int32 // committed_transcripts received
+	final    atomic.Int32 // the number of the last commit once CloseSend sent it
+}
+
+func (e *ElevenLabs) startStream(ctx context.Context, cfg streamSessionConfig) (rawStreamSession, error) {
+	endpoint, err := url.Parse(e.streamURL)
+	if err != nil {
+		return nil, err
+	}
+
+	q := endpoint.Query()
+	q.Set("model_id", cfg.Model)
+	q.Set("audio_format", fmt.Sprintf("pcm_%d", cfg.SampleRate))
+	q.Set("commit_strategy", "manual")
+	if cfg.Language != "" {
+		q.Set("language_code", cfg.Language)
+	}
+	endpoint.RawQuery = q.Encode()
+
+	headers := http.Header{}
+	headers.Set("xi-api-key", e.apiKey)
+
+	conn, streamCtx, cancel, err := dialStream(ctx, "elevenlabs", endpoint.String(), headers)
+	if err != nil {
+		return nil, err
+	}
+	return &elevenLabsStreamSession{conn: conn, ctx: streamCtx, cancel: cancel, sampleRate: cfg.SampleRate}, nil
+}
+
+func (s *elevenLabsStreamSession) send(pcm []byte, commit bool) error {
+	if commit {
+		s.sent.Add(1)
+		s.spoke, s.quietMs = false, 0
+	}
+	msg, err := json.Marshal(map[string]any{
+		"message_type":  "input_audio_chunk",
+		"audio_base_64": base64.StdEncoding.EncodeToString(pcm),
+		"commit":        commit,
+		"sample_rate":   s.sampleRate,
+	})
+	if err != nil {
+		return err
+	}
+	return s.conn.Write(s.ctx, websocket.MessageText, msg)
+}
+
+func (s *elevenLabsStreamSession) Send(pcm []byte) error {
+	if pcmRMS(pcm) < elevenLabsQuietRMS {
+		s.quietMs += len(pcm) * 1000 / (2 * s.sampleRate)
+	} else {
+		s.spoke, s.quietMs = true, 0
+	}
+	return s.send(pcm, s.spoke && s.quietMs >= elevenLabsPauseMs)
+}
+
+func (s *elevenLabsStreamSession) CloseSend() error {
+	// Set before sending: the reply can beat the return of the write.
+	s.final.Store(s.sent.Load() + 1)
+	return s.send(nil, true)
+}
+
+// pcmRMS is the root mean square of s16le samples.
+func pcmRMS(pcm []byte) float64 {
+	n := len(pcm) / 2
+	if n == 0 {
+		return 0
+	}
+	var sum float64
+	for i := range n {
+		v := float64(int16(binary.LittleEndian.Uint16(pcm[2*i:])))
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+func (s *elevenLabsStreamSession) Recv() (streamUpdate, error) {
+	for {
+		_, data, err := s.conn.Read(s.ctx)
+		if err != nil {
+			return streamUpdate{}, err
+		}
+
+		var msg elevenLabsStreamMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return streamUpdate{}, err
+		}
+
+		switch msg.MessageType {
+		case "partial_transcript":
+			return streamUpdate{Transcript: strings.TrimSpace(msg.Text)}, nil
+		case "committed_transcript":
+			n := s.received.Add(1)
+			final := s.final.Load()
+			return streamUpdate{
+				Transcript:   strings.TrimSpace(msg.Text),
+				IsFinal:      true,
+				FromFinalize: final > 0 && n >= final,
+			}, nil
+		case "session_started", "committed_transcript_with_timestamps":
+			continue
+		}
+		// Every failure type (error, auth_error, quota_exceeded, …) carries
+		// "error"; anything else unknown is skipped rather than fatal.
+		if msg.Error != "" || strings.HasSuffix(msg.MessageType, "error") {
+			return streamUpdate{}, fmt.Errorf("elevenlabs %s: %s", msg.MessageType, msg.Error)
+		}
+	}
+}
+
+func (s *elevenLabsStreamSession) Close() error {
+	s.cancel()
+	return s.conn.Close(websocket.StatusNormalClosure, "")
+}
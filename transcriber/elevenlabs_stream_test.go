@@ -0,0 +1,129 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+// TestElevenLabsStreamSession runs a realtime session against a stand-in
+// server: the pause in the audio and CloseSend must each send a commit, both
+// replies must land in the committed text — the tail included, though the
+// pause's reply comes late and the final one later still — the partial must
+// not, and the session must keep the audio and stream stats the Deepgram path
+// produces.
+func TestElevenLabsStreamSession(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("xi-api-key") != "xi-test" || r.URL.Query().Get("model_id") != ModelScribeV2Realtime ||
+			r.URL.Query().Get("commit_strategy") != "manual" {
+			http.Error(w, "bad handshake", http.StatusUnauthorized)
+			return
+		}
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.CloseNow()
+		ctx := r.Context()
+		write := func(v string) { c.Write(ctx, websocket.MessageText, []byte(v)) }
+		write(`{"message_type":"session_started","session_id":"s1"}`)
+
+		chunks, commits := 0, 0
+		for {
+			_, data, err := c.Read(ctx)
+			if err != nil {
+				return
+			}
+			var m struct {
+				Type   string `json:"message_type"`
+				Audio  string `json:"audio_base_64"`
+				Commit bool   `json:"commit"`
+			}
+			if json.Unmarshal(data, &m) != nil || m.Type != "input_audio_chunk" {
+				write(`{"message_type":"input_error","error":"bad message"}`)
+				return
+			}
+			if m.Audio != "" {
+				if chunks++; chunks == 1 {
+					write(`{"message_type":"partial_transcript","text":"hel"}`)
+				}
+			}
+			if !m.Commit {
+				continue
+			}
+			// The pause's reply is held until the final commit is in, and
+			// the final reply takes longer than streamFinalizeIdle.
+			if commits++; commits == 2 {
+				write(`{"message_type":"committed_transcript","text":"hello"}`)
+				time.Sleep(3 * streamFinalizeIdle)
+				write(`{"message_type":"committed_transcript","text":"world"}`)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	e := NewElevenLabs("xi-test")
+	e.streamURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	e.SetModel(ModelScribeV2Realtime)
+
+	sess, err := e.NewSession(context.Background(), SessionConfig{Stream: true, Language: "en"})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	go func() {
+		for range sess.Updates() {
+		}
+	}()
+	// Speech, a pause long enough to commit, then speech again.
+	speech := make([]byte, streamChunkBytes)
+	for i := 0; i < len(speech); i += 2 {
+		binary.LittleEndian.PutUint16(speech[i:], uint16(int16(4000*(1-2*(i/2%2)))))
+	}
+	pcm := slices.Concat(speech, make([]byte, 4*streamChunkBytes), speech)
+	sess.Feed(pcm)
+	res, err := sess.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if res.Text != "hello world" {
+		t.Errorf("Text = %q, want %q", res.Text, "hello world")
+	}
+	if res.Stream == nil || res.Stream.CommitEvents != 2 || res.Stream.RecvInterim != 1 {
+		t.Errorf("Stream stats = %+v, want 2 commits and 1 interim", res.Stream)
+	}
+	if res.AudioFormat != "wav" || len(res.AudioData) <= len(pcm) {
+		t.Errorf("audio not retained: format %q, %d bytes", res.AudioFormat, len(res.AudioData))
+	}
+}
+
+func TestElevenLabsStreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.CloseNow()
+		c.Write(r.Context(), websocket.MessageText, []byte(`{"message_type":"quota_exceeded","error":"out of credits"}`))
+		c.Read(r.Context())
+	}))
+	defer srv.Close()
+
+	e := NewElevenLabs("xi-test")
+	e.streamURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	raw, err := e.startStream(context.Background(), streamSessionConfig{SampleRate: 16000, Model: ModelScribeV2Realtime})
+	if err != nil {
+		t.Fatalf("startStream: %v", err)
+	}
+	defer raw.Close()
+	if _, err := raw.Recv(); err == nil || !strings.Contains(err.Error(), "out of credits") {
+		t.Errorf("Recv err = %v, want the server's quota error", err)
+	}
+}
@@ -89,3 +89,15 @@ func (s *fakeSession) Close() (SessionResult, error) {
 	r.captureRSS()
 	return r, nil
 }
+
+// Transcribe makes the fake a direct transcriber too (the -transcribe and
+// fallback paths), returning the same canned text or error as its sessions.
+func (f *FakeTranscriber) Transcribe(_ []byte, _, _, _ string) (*Result, error) {
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+	if f.err != nil {
+		return nil, fmt.Errorf("fake transcriber error: %w", f.err)
+	}
+	return &Result{Text: f.text}, nil
+}
@@ -89,7 +89,7 @@ func (g *Groq) Transcribe(audioData []byte, format, lang, hints string) (*Result
 		writer.WriteField("language", lang)
 	}
 	if hints != "" {
-		writer.WriteField("prompt", hints)
+		writer.WriteField("prompt", plainHints(hints))
 	}
 	writer.Close()
 
@@ -115,11 +115,25 @@ func (g *Groq) Transcribe(audioData []byte, format, lang, hints string) (*Result
 		return nil, fmt.Errorf("groq response parse error: %w", err)
 	}
 
+	remaining := firstNonEmpty(resp.Header, "x-ratelimit-remaining-requests")
+	limit := firstNonEmpty(resp.Header, "x-ratelimit-limit-requests")
+
+	res := gResp.result()
+	res.Metrics = resp.Metrics
+	res.RateLimit = remaining + "/" + limit
+	return res, nil
+}
+
+// result maps a whisper-style transcription response onto Result. Segments are
+// optional (plain "json" responses omit them); when present, the worst
+// no_speech_prob and the mean avg_logprob summarize the clip. Shared with every
+// provider that speaks the OpenAI verbose_json shape.
+func (r groqResponse) result() *Result {
 	var noSpeechProb, avgLogProb float64
 	var segments []Segment
-	if len(gResp.Segments) > 0 {
+	if len(r.Segments) > 0 {
 		var logProbSum float64
-		for _, seg := range gResp.Segments {
+		for _, seg := range r.Segments {
 			if seg.NoSpeechProb > noSpeechProb {
 				noSpeechProb = seg.NoSpeechProb
 			}
@@ -134,19 +148,13 @@ func (g *Groq) Transcribe(audioData []byte, format, lang, hints string) (*Result
 				End:              seg.End,
 			})
 		}
-		avgLogProb = logProbSum / float64(len(gResp.Segments))
+		avgLogProb = logProbSum / float64(len(r.Segments))
 	}
-
-	remaining := firstNonEmpty(resp.Header, "x-ratelimit-remaining-requests")
-	limit := firstNonEmpty(resp.Header, "x-ratelimit-limit-requests")
-
 	return &Result{
-		Text:         gResp.Text,
-		Metrics:      resp.Metrics,
-		RateLimit:    remaining + "/" + limit,
+		Text:         r.Text,
 		NoSpeechProb: noSpeechProb,
 		AvgLogProb:   avgLogProb,
-		Duration:     gResp.Duration,
+		Duration:     r.Duration,
 		Segments:     segments,
-	}, nil
+	}
 }
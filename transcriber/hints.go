@@ -0,0 +1,46 @@
+package transcriber
+
+import (
+	"strconv"
+	"strings"
+)
+
+// hints.txt entries reach the providers as one comma-separated string. An entry
+// may carry a boost weight, "term:weight" (e.g. "Grafana:2"); only Deepgram
+// can use it, so every other consumer takes plainHints.
+
+type hintTerm struct {
+	Term   string
+	Weight float64 // 0 when the entry has no weight
+}
+
+// parseHints splits the hints string into terms. A trailing ":<number>" is a
+// weight; any other colon is part of the term.
+func parseHints(hints string) []hintTerm {
+	var terms []hintTerm
+	for _, entry := range strings.Split(hints, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		t := hintTerm{Term: entry}
+		if i := strings.LastIndex(entry, ":"); i > 0 {
+			if w, err := strconv.ParseFloat(strings.TrimSpace(entry[i+1:]), 64); err == nil {
+				t = hintTerm{Term: strings.TrimSpace(entry[:i]), Weight: w}
+			}
+		}
+		terms = append(terms, t)
+	}
+	return terms
+}
+
+// plainHints is the hints string with the weights removed, for the providers
+// that take hints as a prompt or a plain term list.
+func plainHints(hints string) string {
+	terms := parseHints(hints)
+	plain := make([]string, len(terms))
+	for i, t := range terms {
+		plain[i] = t.Term
+	}
+	return strings.Join(plain, ", ")
+}
@@ -0,0 +1,64 @@
+package transcriber
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseHints(t *testing.T) {
+	got := parseHints("Grafana:2, Pi.dev ,, C++:x, Node.js:0.5, :3")
+	want := []hintTerm{
+		{"Grafana", 2}, {"Pi.dev", 0}, {"C++:x", 0}, {"Node.js", 0.5}, {":3", 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseHints = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("term %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if p := plainHints("Grafana:2, Pi.dev"); p != "Grafana, Pi.dev" {
+		t.Errorf("plainHints = %q", p)
+	}
+}
+
+// TestAddDeepgramBias: nova-3 gets keyterm without weights, older models get
+// keywords with them, and both put the heaviest terms first.
+func TestAddDeepgramBias(t *testing.T) {
+	q := url.Values{}
+	addDeepgramBias(q, "nova-3", "Bun, Grafana:3, Zee:1.5")
+	if got := strings.Join(q["keyterm"], ","); got != "Grafana,Zee,Bun" {
+		t.Errorf("nova-3 keyterm = %q, want heaviest first without weights", got)
+	}
+	if q.Has("keywords") {
+		t.Error("nova-3 should not get keywords")
+	}
+
+	q = url.Values{}
+	addDeepgramBias(q, "nova-2", "Bun, Grafana:3")
+	if got := strings.Join(q["keywords"], ","); got != "Grafana:3,Bun" {
+		t.Errorf("nova-2 keywords = %q, want weights kept", got)
+	}
+}
+
+// TestAddDeepgramBiasCapsQuery: a huge hints.txt must not push the URL past
+// the cap — the tail is dropped, the weighted terms survive.
+func TestAddDeepgramBiasCapsQuery(t *testing.T) {
+	terms := []string{"Important:9"}
+	for range 2000 {
+		terms = append(terms, "filler-term")
+	}
+	q := url.Values{}
+	addDeepgramBias(q, "nova-3", strings.Join(terms, ","))
+	if n := len(q.Encode()); n > deepgramMaxBiasQuery {
+		t.Errorf("encoded query = %d bytes, want <= %d", n, deepgramMaxBiasQuery)
+	}
+	if q["keyterm"][0] != "Important" {
+		t.Errorf("first keyterm = %q, want the weighted term kept", q["keyterm"][0])
+	}
+	if len(q["keyterm"]) >= len(terms) {
+		t.Error("nothing was dropped")
+	}
+}
@@ -32,6 +32,7 @@ type localEngine interface {
 type localProvider struct {
 	mu       sync.Mutex // guards the fields below; held only briefly, never across a model load
 	loadMu   sync.Mutex // serializes load() so concurrent triggers can't double-load
+	inferMu  sync.Mutex // one inference at a time on the shared engine; freeing it waits for the run
 	modelID  string     // desired model
 	loadedID string     // model currently loaded ("" while unloaded or loading)
 	engine   localEngine
@@ -151,7 +152,9 @@ func (p *localProvider) load() {
 	p.mu.Unlock()
 
 	if old != nil {
+		p.inferMu.Lock()
 		old.Close()
+		p.inferMu.Unlock()
 	}
 
 	var (
@@ -236,17 +239,14 @@ func (p *localProvider) SetModel(id string) {
 	go p.load()
 }
 
-// Transcribe decodes a WAV file to PCM and runs one batch inference, satisfying
-// the same direct-transcribe interface as the cloud providers so the file path
-// (-transcribe) has a single shape. Local decode accepts WAV only; hints reach
+// Transcribe decodes a WAV, MP3 or FLAC file to PCM and runs one batch
+// inference, satisfying the same direct-transcribe interface as the cloud
+// providers so the file path (-transcribe) has a single shape. Hints reach
 // whichever engine can use them.
 func (p *localProvider) Transcribe(audioData []byte, format, lang, hints string) (*Result, error) {
-	if format != "wav" {
-		return nil, fmt.Errorf("local transcription supports WAV files only (got %s)", format)
-	}
-	pcm, err := audio.WAVToPCM(audioData)
+	pcm, err := audio.DecodeToPCM(audioData, format)
 	if err != nil {
-		return nil, fmt.Errorf("cannot read WAV: %w", err)
+		return nil, fmt.Errorf("cannot decode %s: %w", format, err)
 	}
 	sess, err := p.NewSession(context.Background(), SessionConfig{Language: lang, Hints: hints})
 	if err != nil {
@@ -293,7 +293,7 @@ func (p *localProvider) NewSession(_ context.Context, cfg SessionConfig) (Sessio
 	if cfg.Language != "" {
 		lang = cfg.Language
 	}
-	return &localSession{engine: eng, lang: lang, hints: cfg.Hints, updates: make(chan string)}, nil
+	return &localSession{engine: eng, infer: &p.inferMu, lang: lang, hints: plainHints(cfg.Hints), updates: make(chan string)}, nil
 }
 
 // Close frees the loaded model. It waits out any in-flight background load
@@ -307,6 +307,8 @@ func (p *localProvider) Close() {
 	p.engine, p.loadedID, p.loadErr = nil, "", nil
 	p.mu.Unlock()
 	if eng != nil {
+		p.inferMu.Lock()
 		eng.Close()
+		p.inferMu.Unlock()
 	}
 }
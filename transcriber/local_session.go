@@ -15,6 +15,7 @@ import (
 // live hotkey and -transcribe share it — no encoder, no network.
 type localSession struct {
 	engine  localEngine
+	infer   *sync.Mutex // the provider's: sessions may close concurrently (zee serve, chunked dictation)
 	lang    string
 	hints   string
 	mu      sync.Mutex
@@ -47,8 +48,10 @@ func (s *localSession) Close() (SessionResult, error) {
 	audioData := audio.PCMToWAV(raw)
 	convertMs := float64(time.Since(convStart).Microseconds()) / 1000
 
+	s.infer.Lock()
 	start := time.Now()
 	text, err := s.engine.Transcribe(f32, s.lang, s.hints)
+	s.infer.Unlock()
 	if err != nil {
 		return SessionResult{AudioData: audioData, AudioFormat: "wav"}, err
 	}
@@ -8,7 +8,6 @@ import (
 	"mime/multipart"
 	"net/http"
 	"strconv"
-	"strings"
 )
 
 var voxtralLangs = langsFromCodes([]string{
@@ -66,8 +65,8 @@ func (m *Mistral) Transcribe(audioData []byte, format, lang, hints string) (*Res
 		writer.WriteField("language", lang)
 	}
 	if hints != "" {
-		for _, word := range strings.Split(hints, ",") {
-			writer.WriteField("context_bias[]", strings.TrimSpace(word))
+		for _, t := range parseHints(hints) {
+			writer.WriteField("context_bias[]", t.Term)
 		}
 	}
 	writer.Close()
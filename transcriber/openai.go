@@ -1,12 +1,15 @@
 package transcriber
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
+	"strings"
 )
 
 type OpenAI struct {
@@ -38,21 +41,121 @@ var gpt4oTranscribeLangs = langsFromCodes([]string{
 	"ta", "th", "tr", "uk", "ur", "vi", "cy",
 })
 
+// openAIStreamSuffix marks the streamed variant of a model in the picker. It is
+// the same API model; the suffix only gives the tray (and config.json) a
+// separate entry, since Stream is a property of the ModelInfo.
+const openAIStreamSuffix = "-stream"
+
 var OpenAIModels = []ModelInfo{
 	{ID: "gpt-4o-transcribe", Label: "GPT-4o Transcribe", Stream: false, Languages: gpt4oTranscribeLangs},
+	{ID: "gpt-4o-transcribe" + openAIStreamSuffix, Label: "GPT-4o Transcribe (stream)", Stream: true, Languages: gpt4oTranscribeLangs},
 }
 
 func (o *OpenAI) Models() []ModelInfo { return OpenAIModels }
 
+// apiModel is the model name sent to the API: the picker ID minus the stream
+// marker.
+func (o *OpenAI) apiModel() string {
+	return strings.TrimSuffix(o.GetModel(), openAIStreamSuffix)
+}
+
+// NewSession: OpenAI has no live-audio endpoint for transcription models, so
+// "stream" here means the response — the recording is uploaded on release as in
+// batch mode, and the transcript comes back as server-sent deltas that reach
+// Updates() (and auto-paste) before the request has finished.
 func (o *OpenAI) NewSession(_ context.Context, cfg SessionConfig) (Session, error) {
 	go o.client.Warm()
 	if cfg.Stream {
-		return nil, fmt.Errorf("openai does not support streaming transcription")
+		return newPartialBatchSession(cfg, o.transcribeStream)
 	}
 	return newBatchSession(cfg, o.Transcribe)
 }
 
 func (o *OpenAI) Transcribe(audioData []byte, format, lang, hints string) (*Result, error) {
+	req, err := o.newRequest(audioData, format, lang, hints, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("openai API error %d: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	var oResp struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(resp.Body, &oResp); err != nil {
+		return nil, fmt.Errorf("openai response parse error: %w", err)
+	}
+
+	remaining := firstNonEmpty(resp.Header, "x-ratelimit-remaining-requests")
+	limit := firstNonEmpty(resp.Header, "x-ratelimit-limit-requests")
+
+	return &Result{
+		Text:      oResp.Text,
+		Metrics:   resp.Metrics,
+		RateLimit: remaining + "/" + limit,
+	}, nil
+}
+
+// transcribeStream is Transcribe with stream=true: the response is an SSE
+// stream of transcript.text.delta events closed by transcript.text.done, which
+// carries the full text. partial gets the running text after every delta.
+func (o *OpenAI) transcribeStream(audioData []byte, format, lang, hints string, partial func(string)) (*Result, error) {
+	req, err := o.newRequest(audioData, format, lang, hints, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	var final string
+	var done bool
+	resp, err := o.client.DoStream(req, func(body io.Reader) error {
+		return readSSE(body, func(data []byte) error {
+			var ev struct {
+				Type  string `json:"type"`
+				Delta string `json:"delta"`
+				Text  string `json:"text"`
+			}
+			if err := json.Unmarshal(data, &ev); err != nil {
+				return fmt.Errorf("openai stream parse error: %w", err)
+			}
+			switch ev.Type {
+			case "transcript.text.delta":
+				text.WriteString(ev.Delta)
+				partial(text.String())
+			case "transcript.text.done":
+				final, done = ev.Text, true
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("openai API error %d: %s", resp.StatusCode, string(resp.Body))
+	}
+	if !done {
+		return nil, fmt.Errorf("openai stream ended without transcript.text.done")
+	}
+
+	remaining := firstNonEmpty(resp.Header, "x-ratelimit-remaining-requests")
+	limit := firstNonEmpty(resp.Header, "x-ratelimit-limit-requests")
+
+	return &Result{
+		Text:      final,
+		Metrics:   resp.Metrics,
+		RateLimit: remaining + "/" + limit,
+	}, nil
+}
+
+func (o *OpenAI) newRequest(audioData []byte, format, lang, hints string, stream bool) (*http.Request, error) {
 	var body bytes.Buffer
 	writer := multipart.NewWriter(&body)
 
@@ -64,13 +167,16 @@ func (o *OpenAI) Transcribe(audioData []byte, format, lang, hints string) (*Resu
 		return nil, err
 	}
 
-	writer.WriteField("model", o.GetModel())
+	writer.WriteField("model", o.apiModel())
 	writer.WriteField("response_format", "json")
+	if stream {
+		writer.WriteField("stream", "true")
+	}
 	if lang != "" {
 		writer.WriteField("language", lang)
 	}
 	if hints != "" {
-		writer.WriteField("prompt", hints)
+		writer.WriteField("prompt", plainHints(hints))
 	}
 	if err := writer.Close(); err != nil {
 		return nil, err
@@ -83,29 +189,51 @@ func (o *OpenAI) Transcribe(audioData []byte, format, lang, hints string) (*Resu
 
 	req.Header.Set("Authorization", "Bearer "+o.apiKey)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	resp, err := o.client.Do(req)
-	if err != nil {
-		return nil, err
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
 	}
+	return req, nil
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("openai API error %d: %s", resp.StatusCode, string(resp.Body))
+// readSSE splits a server-sent-events body into events and calls fn with each
+// one's data (multi-line data joined by "\n"). Comments, event names and ids
+// carry nothing OpenAI's transcription stream needs; a "[DONE]" sentinel ends
+// the stream.
+func readSSE(r io.Reader, fn func(data []byte) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var data []byte
+	flush := func() error {
+		if len(data) == 0 {
+			return nil
+		}
+		d := data
+		data = nil
+		return fn(d)
 	}
-
-	var oResp struct {
-		Text string `json:"text"`
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		v, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		v = strings.TrimPrefix(v, " ")
+		if v == "[DONE]" {
+			return nil
+		}
+		if len(data) > 0 {
+			data = append(data, '\n')
+		}
+		data = append(data, v...)
 	}
-	if err := json.Unmarshal(resp.Body, &oResp); err != nil {
-		return nil, fmt.Errorf("openai response parse error: %w", err)
+	if err := sc.Err(); err != nil {
+		return err
 	}
-
-	remaining := firstNonEmpty(resp.Header, "x-ratelimit-remaining-requests")
-	limit := firstNonEmpty(resp.Header, "x-ratelimit-limit-requests")
-
-	return &Result{
-		Text:      oResp.Text,
-		Metrics:   resp.Metrics,
-		RateLimit: remaining + "/" + limit,
-	}, nil
+	return flush()
 }
@@ -0,0 +1,99 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"zee/encoder"
+)
+
+// TestOpenAIStreamSession drives the stream model end to end against a local
+// SSE server: the request must ask for stream=true with the API's model name,
+// each delta must reach Updates() as cumulative text before Close returns, and
+// the final text comes from transcript.text.done.
+func TestOpenAIStreamSession(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("stream") != "true" || r.FormValue("model") != "gpt-4o-transcribe" {
+			http.Error(w, "stream="+r.FormValue("stream")+" model="+r.FormValue("model"), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fl := w.(http.Flusher)
+		for _, d := range []string{" Hello", " there,", " world."} {
+			fmt.Fprintf(w, "data: {\"type\":\"transcript.text.delta\",\"delta\":%q}\n\n", d)
+			fl.Flush()
+			time.Sleep(10 * time.Millisecond)
+		}
+		done := `data: {"type":"transcript.text.done","text":"Hello there, world."}` + "\n\n"
+		fmt.Fprint(w, done)
+	}))
+	defer srv.Close()
+
+	o := NewOpenAI("sk-test")
+	o.apiURL = srv.URL
+	o.client = NewTracedClient(srv.URL)
+	o.SetModel("gpt-4o-transcribe" + openAIStreamSuffix)
+
+	sess, err := o.NewSession(context.Background(), SessionConfig{Stream: true, Format: "mp3@16"})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	var updates []string
+	drained := make(chan struct{})
+	go func() {
+		for u := range sess.Updates() {
+			updates = append(updates, u)
+		}
+		close(drained)
+	}()
+
+	sess.Feed(make([]byte, encoder.BlockSize*4))
+	res, err := sess.Close()
+	<-drained
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if res.Text != "Hello there, world." || !res.HasText {
+		t.Errorf("Text = %q, want the done event's text", res.Text)
+	}
+	want := []string{"Hello", "Hello there,", "Hello there, world."}
+	if strings.Join(updates, "|") != strings.Join(want, "|") {
+		t.Errorf("updates = %q, want %q", updates, want)
+	}
+	if res.Batch == nil {
+		t.Error("Batch stats should be set: the upload is a batch request")
+	}
+}
+
+func TestReadSSE(t *testing.T) {
+	body := ": keep-alive\n" +
+		"event: message\n" +
+		"data: one\n\n" +
+		"data: two\n" +
+		"data: lines\n\n" +
+		"data: [DONE]\n\n" +
+		"data: after\n\n"
+	var got []string
+	err := readSSE(strings.NewReader(body), func(d []byte) error {
+		got = append(got, string(d))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readSSE: %v", err)
+	}
+	if want := []string{"one", "two\nlines"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
@@ -39,6 +39,8 @@ type BatchStats struct {
 	Confidence       float64
 	InferenceMs      float64
 	ConvertMs        float64 // local path: PCM→f32 + PCM→WAV conversion before inference
+	Retries          int     // HTTP attempts retried before the one that answered
+	RetryMs          float64 // latency those retries added (their time + backoff)
 }
 
 type StreamStats struct {
@@ -63,7 +65,7 @@ type SessionResult struct {
 	Batch        *BatchStats  // non-nil for batch sessions
 	Stream       *StreamStats // non-nil for stream sessions
 	Metrics      []string     // pre-formatted metric lines
-	AudioData    []byte       // exact bytes sent to the model
+	AudioData    []byte       `json:"-"` // exact bytes sent to the model
 	AudioFormat  string       // "mp3", "flac", or "wav"
 }
 
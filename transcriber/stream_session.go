@@ -32,13 +32,13 @@ type streamUpdate struct {
 }
 
 type streamSession struct {
+	provider  string // for the metrics block
 	ws        rawStreamSession
 	committed string
 	audioCh   chan []byte
 	updates   chan string
 	startedAt time.Time
 	connected chan struct{} // closed when WebSocket is ready (or failed)
-	// hints string // TODO: Deepgram streaming supports keywords param
 
 	sendDone      chan struct{}
 	recvDone      chan struct{}
@@ -72,8 +72,9 @@ func (s streamStats) audioDuration() float64 {
 	return float64(s.SentBytes) / float64(encoder.SampleRate*encoder.Channels*(encoder.BitsPerSample/8))
 }
 
-func newStreamSession(dial func() (rawStreamSession, error)) *streamSession {
+func newStreamSession(provider string, dial func() (rawStreamSession, error)) *streamSession {
 	ss := &streamSession{
+		provider:  provider,
 		audioCh:   make(chan []byte, 128),
 		updates:   make(chan string, 16),
 		startedAt: time.Now(),
@@ -354,7 +355,7 @@ func (s *streamSession) formatMetrics(stats streamStats) []string {
 
 	return []string{
 		fmt.Sprintf("audio:      %.1fs | %.1f KB PCM sent", audioDuration, float64(stats.SentBytes)/1024),
-		fmt.Sprintf("stream:     deepgram | PCM16 %dHz mono | %dms chunks", encoder.SampleRate, streamChunkMs),
+		fmt.Sprintf("stream:     %s | PCM16 %dHz mono | %dms chunks", s.provider, encoder.SampleRate, streamChunkMs),
 		fmt.Sprintf("connect:    %dms", stats.ConnectDur.Milliseconds()),
 		fmt.Sprintf("sent:       %d chunks | %.1f KB", stats.SentChunks, float64(stats.SentBytes)/1024),
 		fmt.Sprintf("recv:       %d msgs (%d final, %d interim)", stats.RecvMessages, stats.RecvFinal, stats.RecvInterim),
@@ -77,7 +77,7 @@ func testPCM() []byte {
 // path persist.
 func TestStreamSessionRetainsAudio(t *testing.T) {
 	f := newFakeRawStream()
-	ss := newStreamSession(func() (rawStreamSession, error) { return f, nil })
+	ss := newStreamSession("fake", func() (rawStreamSession, error) { return f, nil })
 
 	pcm := testPCM()
 	ss.Feed(pcm)
@@ -107,7 +107,7 @@ func TestStreamSessionRetainsAudio(t *testing.T) {
 // to samples/.
 func TestStreamSessionRetainsAudioOnConnectError(t *testing.T) {
 	dialErr := errors.New("dial tcp: network is unreachable")
-	ss := newStreamSession(func() (rawStreamSession, error) { return nil, dialErr })
+	ss := newStreamSession("fake", func() (rawStreamSession, error) { return nil, dialErr })
 
 	// Wait for the dial to fail so Feed deterministically hits the post-error
 	// path — audio fed after the failure must be retained too.
@@ -1,18 +1,46 @@
 package transcriber
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"io"
+	"math/rand/v2"
 	"net"
 	"net/http"
 	"net/http/httptrace"
 	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
+
+	"zee/log"
 )
 
 type TracedClient struct {
 	client  *http.Client
 	warmURL string
+	retry   RetryPolicy
+}
+
+// RetryPolicy bounds how TracedClient retries a transient failure (429, 5xx
+// gateway errors, a connection dropped before the response). The user is
+// waiting on the result, so the budget is small: a server asking for a longer
+// wait than MaxDelay gets no retry — the error surfaces (and the fallback
+// chain, if configured, takes over) instead of the app sitting on the
+// "transcribing" icon.
+type RetryPolicy struct {
+	MaxAttempts int           // total tries, the first included; 1 disables retrying
+	BaseDelay   time.Duration // first backoff; doubles per attempt, with jitter
+	MaxDelay    time.Duration // cap on any single wait, server-requested ones included
+}
+
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    4 * time.Second,
 }
 
 func NewTracedClient(apiURL string) *TracedClient {
@@ -42,6 +70,7 @@ func NewTracedClient(apiURL string) *TracedClient {
 			},
 		},
 		warmURL: warmURL,
+		retry:   defaultRetryPolicy,
 	}
 	go tc.Warm()
 	return tc
@@ -54,7 +83,172 @@ type TracedResponse struct {
 	Metrics    *NetworkMetrics
 }
 
+// Do sends req, retrying transient failures per c.retry. Transcription POSTs
+// are safe to replay (no server-side state), but only when the body can be
+// rewound — http.NewRequest sets GetBody for the in-memory bodies every
+// provider builds. The returned Metrics describe the final attempt; earlier
+// ones are kept in Metrics.Retries along with the backoff slept between them.
 func (c *TracedClient) Do(req *http.Request) (*TracedResponse, error) {
+	return c.send(req, nil)
+}
+
+// DoStream is Do for a response read as it arrives (server-sent events): a 200
+// body is handed to consume instead of being buffered, and the returned
+// TracedResponse has no Body. Non-200 responses are buffered and retried as in
+// Do. Once consume has started nothing is retried — the caller may already
+// have acted on part of the stream.
+func (c *TracedClient) DoStream(req *http.Request, consume func(body io.Reader) error) (*TracedResponse, error) {
+	return c.send(req, consume)
+}
+
+func (c *TracedClient) send(req *http.Request, consume func(io.Reader) error) (*TracedResponse, error) {
+	var retries []*NetworkMetrics
+	var waited time.Duration
+	for attempt := 1; ; attempt++ {
+		resp, metrics, err := c.do(req, consume)
+		metrics.Retries, metrics.RetryWait = retries, waited
+		if err != nil && resp != nil { // failed mid-stream
+			return nil, err
+		}
+
+		wait, retry := c.retryDelay(attempt, resp, err)
+		if retry && req.Body != nil && req.GetBody == nil {
+			retry = false
+		}
+		if !retry {
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+
+		reason := "network error: " + fmt.Sprint(err)
+		if err == nil {
+			reason = "status " + strconv.Itoa(resp.StatusCode)
+		}
+		log.Warnf("http retry %d/%d in %dms (%s)", attempt, c.retry.MaxAttempts-1, wait.Milliseconds(), reason)
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+		if req.GetBody != nil {
+			body, gerr := req.GetBody()
+			if gerr != nil {
+				return nil, gerr
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		retries = append(retries, metrics)
+		waited += wait
+	}
+}
+
+// retryDelay decides whether attempt's outcome is worth another try, and how
+// long to wait first. A server-provided delay (Retry-After, or a rate-limit
+// reset on a 429) is honored as-is; otherwise the backoff is exponential with
+// ±50% jitter so clients that failed together don't retry together.
+func (c *TracedClient) retryDelay(attempt int, resp *TracedResponse, err error) (time.Duration, bool) {
+	if attempt >= c.retry.MaxAttempts {
+		return 0, false
+	}
+	if err != nil {
+		if !retryableErr(err) {
+			return 0, false
+		}
+	} else if !retryableStatus(resp.StatusCode) {
+		return 0, false
+	}
+
+	if resp != nil {
+		if d, ok := serverDelay(resp.Header, resp.StatusCode, time.Now()); ok {
+			return d, d <= c.retry.MaxDelay
+		}
+	}
+	backoff := c.retry.BaseDelay << (attempt - 1)
+	backoff = backoff/2 + rand.N(backoff+1)
+	return min(backoff, c.retry.MaxDelay), true
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryableErr allows what a second attempt can fix: a refused, reset or
+// aborted connection, and a connection that closed mid-response (io.EOF is
+// the idle keep-alive the server dropped as the request went out). Anything
+// else — a TLS or certificate failure, an unknown host, a malformed URL, a
+// timeout (each phase timeout is already seconds long) — fails the same way
+// again, so it is final.
+func retryableErr(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return false
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// serverDelay reads how long the server asked us to wait: Retry-After (seconds
+// or an HTTP date), else — on a 429 only — the reset of whichever rate limit is
+// exhausted. Groq and OpenAI send the resets as Go-style durations
+// ("2m59.56s", "7.66s", "6ms").
+func serverDelay(h http.Header, status int, now time.Time) (time.Duration, bool) {
+	if v := strings.TrimSpace(h.Get("Retry-After")); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(t.Sub(now), 0), true
+		}
+	}
+	if status != http.StatusTooManyRequests {
+		return 0, false
+	}
+	var d time.Duration
+	var found bool
+	for _, kind := range []string{"requests", "tokens"} {
+		if h.Get("x-ratelimit-remaining-"+kind) != "0" {
+			continue
+		}
+		if r, ok := parseReset(h.Get("x-ratelimit-reset-" + kind)); ok {
+			d, found = max(d, r), true
+		}
+	}
+	return d, found
+}
+
+func parseReset(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	d, err := time.ParseDuration(v)
+	return d, err == nil
+}
+
+// do is one traced attempt. The metrics are returned even when the request
+// fails, so a retried attempt still accounts for the time it cost. With a
+// consume func, a 200 body is streamed to it; an error from there comes back
+// with a non-nil response so send knows not to retry.
+func (c *TracedClient) do(req *http.Request, consume func(io.Reader) error) (*TracedResponse, *NetworkMetrics, error) {
 	metrics := &NetworkMetrics{}
 	var getConnStart, dnsStart, tcpStart, tlsStart time.Time
 	var gotConn, wroteHeaders, wroteRequest, firstByte time.Time
@@ -94,13 +288,23 @@ func (c *TracedClient) Do(req *http.Request) (*TracedResponse, error) {
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, err
+		metrics.Total = time.Since(reqStart)
+		return nil, metrics, err
 	}
 	defer resp.Body.Close()
 
+	if consume != nil && resp.StatusCode == http.StatusOK {
+		tr := &TracedResponse{StatusCode: resp.StatusCode, Header: resp.Header, Metrics: metrics}
+		err := consume(resp.Body)
+		metrics.Download = time.Since(firstByte)
+		metrics.Total = time.Since(reqStart)
+		return tr, metrics, err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		metrics.Total = time.Since(reqStart)
+		return nil, metrics, err
 	}
 	metrics.Download = time.Since(firstByte)
 	metrics.Total = time.Since(reqStart)
@@ -110,7 +314,7 @@ func (c *TracedClient) Do(req *http.Request) (*TracedResponse, error) {
 		StatusCode: resp.StatusCode,
 		Header:     resp.Header,
 		Metrics:    metrics,
-	}, nil
+	}, metrics, nil
 }
 
 func (c *TracedClient) Warm() {
@@ -0,0 +1,157 @@
+package transcriber
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func fastRetryClient(url string) *TracedClient {
+	c := NewTracedClient(url)
+	c.retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	return c
+}
+
+// TestTracedClientRetriesTransient: a 503 then a 200 must succeed on the second
+// attempt with the full body replayed, and the first attempt's metrics kept.
+func TestTracedClientRetriesTransient(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost { // the constructor's warm-up HEAD
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "AUDIO" {
+			http.Error(w, "body not replayed: "+string(body), http.StatusBadRequest)
+			return
+		}
+		if calls.Add(1) == 1 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("POST", srv.URL, bytes.NewBufferString("AUDIO"))
+	resp, err := fastRetryClient(srv.URL).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.StatusCode != 200 || string(resp.Body) != "ok" {
+		t.Fatalf("got %d %q, want 200 ok", resp.StatusCode, resp.Body)
+	}
+	if n := len(resp.Metrics.Retries); n != 1 {
+		t.Errorf("Retries = %d, want 1", n)
+	}
+	if resp.Metrics.RetryCost() <= 0 {
+		t.Error("RetryCost should account for the failed attempt")
+	}
+}
+
+// TestTracedClientNoRetry: client errors are final, attempts are bounded, and a
+// server asking for more than MaxDelay is not waited on.
+func TestTracedClientNoRetry(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		status     int
+		retryAfter string
+		wantCalls  int32
+	}{
+		{"bad request", http.StatusBadRequest, "", 1},
+		{"always 502", http.StatusBadGateway, "", 3},
+		{"retry-after too long", http.StatusTooManyRequests, "30", 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost { // the constructor's warm-up HEAD
+					return
+				}
+				calls.Add(1)
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			req, _ := http.NewRequest("POST", srv.URL, bytes.NewBufferString("AUDIO"))
+			resp, err := fastRetryClient(srv.URL).Do(req)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("attempts = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestServerDelay(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	hdr := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+	for _, tt := range []struct {
+		name   string
+		h      http.Header
+		status int
+		want   time.Duration
+		ok     bool
+	}{
+		{"retry-after seconds", hdr("Retry-After", "2"), 503, 2 * time.Second, true},
+		{"retry-after date", hdr("Retry-After", now.Add(3*time.Second).Format(http.TimeFormat)), 429, 3 * time.Second, true},
+		{"exhausted requests", hdr("x-ratelimit-remaining-requests", "0", "x-ratelimit-reset-requests", "1.5s"), 429, 1500 * time.Millisecond, true},
+		{"exhausted both, longest wins", hdr(
+			"x-ratelimit-remaining-requests", "0", "x-ratelimit-reset-requests", "6ms",
+			"x-ratelimit-remaining-tokens", "0", "x-ratelimit-reset-tokens", "2m59.56s"), 429, 2*time.Minute + 59560*time.Millisecond, true},
+		{"reset of a limit not exhausted", hdr("x-ratelimit-remaining-requests", "12", "x-ratelimit-reset-requests", "1s"), 429, 0, false},
+		{"reset ignored outside 429", hdr("x-ratelimit-remaining-requests", "0", "x-ratelimit-reset-requests", "1s"), 503, 0, false},
+	} {
+		got, ok := serverDelay(tt.h, tt.status, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: serverDelay = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestRetryableErr: only failures a second attempt can fix are retried.
+func TestRetryableErr(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		{"cut short", fmt.Errorf("reading body: %w", io.ErrUnexpectedEOF), true},
+		{"unknown authority", &url.Error{Op: "Post", Err: x509.UnknownAuthorityError{}}, false},
+		{"handshake", &url.Error{Op: "Post", Err: errors.New("tls: handshake failure")}, false},
+		{"no such host", &net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{"canceled", context.Canceled, false},
+	} {
+		if got := retryableErr(tt.err); got != tt.want {
+			t.Errorf("%s: retryableErr = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
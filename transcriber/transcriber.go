@@ -22,12 +22,26 @@ type NetworkMetrics struct {
 	Total       time.Duration
 	ConnReused  bool
 	TLSProtocol string
+	// Retries holds the metrics of earlier attempts that TracedClient retried
+	// (oldest first); the fields above describe the attempt that answered.
+	Retries   []*NetworkMetrics
+	RetryWait time.Duration // backoff slept between attempts
 }
 
 func (m *NetworkMetrics) Sum() time.Duration {
 	return m.ConnWait + m.DNS + m.TCP + m.TLS + m.ReqHeaders + m.ReqBody + m.TTFB + m.Download
 }
 
+// RetryCost is the latency the retries added on top of Sum: every earlier
+// attempt's wall time plus the backoff between them.
+func (m *NetworkMetrics) RetryCost() time.Duration {
+	d := m.RetryWait
+	for _, r := range m.Retries {
+		d += r.Total
+	}
+	return d
+}
+
 func firstNonEmpty(h http.Header, keys ...string) string {
 	for _, k := range keys {
 		if v := h.Get(k); v != "" {
@@ -190,6 +204,7 @@ type ProviderInfo struct {
 	Label        string
 	Models       []ModelInfo
 	Local        bool               // on-device engine: keyless, models on disk
+	Keyless      bool               // self-hosted server from config.json; no API key to prompt for
 	DefaultModel string             // the model a fresh instance loads (local only)
 	Available    func() bool        // at least one model usable right now
 	New          func() Transcriber // keyless: closes over the key / model dir
@@ -207,6 +222,23 @@ var keySource = func(string) string { return "" }
 // once, before any provider is used.
 func SetKeySource(fn func(provider string) string) { keySource = fn }
 
+// Server is a self-hosted provider's entry in config.json: the address it
+// listens on and, for a server that hosts whatever model it is asked for
+// (compatible), the model name to send. Keyless providers are gated on Addr.
+type Server struct {
+	Addr  string
+	Model string
+}
+
+// serverSource resolves a self-hosted provider's server by provider name
+// (e.g. "vosk" → ws://localhost:2700). Injected like keySource; the default
+// resolves nothing, which leaves those providers unavailable.
+var serverSource = func(string) Server { return Server{} }
+
+// SetServerSource installs the provider→server resolver. setup.WireProviders
+// wires this to config.json; tests inject their own.
+func SetServerSource(fn func(provider string) Server) { serverSource = fn }
+
 // cloudProvider builds a key-gated ProviderInfo. Availability is "key present";
 // every model shares that status and nothing is downloadable. The key is
 // resolved by provider name through the injected keySource.
@@ -235,6 +267,9 @@ func Providers() []ProviderInfo {
 		cloudProvider("groq", "Groq", GroqModels, func(k string) Transcriber { return NewGroq(k) }),
 		cloudProvider("mistral", "Mistral", MistralModels, func(k string) Transcriber { return NewMistral(k) }),
 		cloudProvider("elevenlabs", "ElevenLabs", ElevenLabsModels, func(k string) Transcriber { return NewElevenLabs(k) }),
+		compatibleProvider(),
+		voskProvider(),
+		wyomingProvider(),
 	}
 }
 
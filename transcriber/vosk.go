@@ -0,0 +1,200 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"nhooyr.io/websocket"
+
+	"zee/audio"
+	"zee/encoder"
+)
+
+// Vosk streams PCM to a vosk-server (Kaldi) WebSocket — typically a sidecar on
+// localhost — for offline, real-time dictation where the on-device engines
+// don't build (they are darwin/arm64-only). The protocol: a {"config": …} text
+// message, binary PCM frames each answered with {"partial": …} or a final
+// {"text": …}, and {"eof" : 1} to flush the last result.
+
+const voskModelID = "vosk"
+
+// voskLangs: the language is whatever model the server loaded; there is nothing
+// to choose client-side.
+var voskLangs = []Language{{"", "Auto-detect"}}
+
+var VoskModels = []ModelInfo{
+	{ID: voskModelID, Label: "Vosk server (stream)", Stream: true, Languages: voskLangs},
+}
+
+type Vosk struct {
+	baseTranscriber
+	url string
+}
+
+func NewVosk(url string) *Vosk {
+	return &Vosk{
+		baseTranscriber: baseTranscriber{model: voskModelID},
+		url:             strings.TrimSpace(url),
+	}
+}
+
+func (v *Vosk) SupportedLanguages() []Language { return voskLangs }
+func (v *Vosk) Name() string                   { return "vosk" }
+func (v *Vosk) Models() []ModelInfo            { return VoskModels }
+
+// NewSession always streams: vosk-server has no batch endpoint.
+func (v *Vosk) NewSession(ctx context.Context, _ SessionConfig) (Session, error) {
+	return newStreamSession("vosk", func() (rawStreamSession, error) {
+		return v.startStream(ctx, encoder.SampleRate)
+	}), nil
+}
+
+// voskProvider is gated on config.json's "vosk" URL: no key, nothing to download.
+func voskProvider() ProviderInfo {
+	const name = "vosk"
+	configured := func() bool { return serverSource(name).Addr != "" }
+	return ProviderInfo{
+		Name:      name,
+		Label:     "Vosk server",
+		Models:    VoskModels,
+		Keyless:   true,
+		Available: configured,
+		New:       func() Transcriber { return NewVosk(serverSource(name).Addr) },
+		Status:    func(string) ModelStatus { return ModelStatus{Ready: configured()} },
+	}
+}
+
+// Transcribe streams an audio file through a session, for -transcribe and the
+// fallback chain; the server takes raw PCM, so the file is decoded first.
+func (v *Vosk) Transcribe(audioData []byte, format, _, _ string) (*Result, error) {
+	pcm, err := audio.DecodeToPCM(audioData, format)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := v.NewSession(context.Background(), SessionConfig{Stream: true})
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for range sess.Updates() {
+		}
+	}()
+	for len(pcm) > 0 {
+		n := min(len(pcm), streamChunkBytes)
+		sess.Feed(pcm[:n])
+		pcm = pcm[n:]
+	}
+	sr, err := sess.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Text: sr.Text}, nil
+}
+
+type voskStreamMessage struct {
+	Partial *string `json:"partial"`
+	Text    *string `json:"text"`
+}
+
+type voskStreamSession struct {
+	conn   *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+	// closing is set once {"eof" : 1} is on its way. The result answering it
+	// is the server's last message — it hangs up right after — so from then
+	// on each final result is held until the next read shows whether the
+	// connection ended behind it.
+	closing atomic.Bool
+	ahead   []byte // a message read past a held result
+	flushed bool   // the eof result was returned
+}
+
+// voskEOF is the end-of-stream message, byte for byte: vosk-server compares
+// the string, so any other spelling is taken for audio.
+const voskEOF = `{"eof" : 1}`
+
+func (v *Vosk) startStream(ctx context.Context, sampleRate int) (rawStreamSession, error) {
+	if v.url == "" {
+		return nil, fmt.Errorf("vosk: no server URL configured")
+	}
+	conn, streamCtx, cancel, err := dialStream(ctx, "vosk", v.url, http.Header{})
+	if err != nil {
+		return nil, err
+	}
+	cfg := fmt.Sprintf(`{"config":{"sample_rate":%d}}`, sampleRate)
+	if err := conn.Write(streamCtx, websocket.MessageText, []byte(cfg)); err != nil {
+		cancel()
+		conn.Close(websocket.StatusInternalError, "")
+		return nil, err
+	}
+	return &voskStreamSession{conn: conn, ctx: streamCtx, cancel: cancel}, nil
+}
+
+func (s *voskStreamSession) Send(pcm []byte) error {
+	return s.conn.Write(s.ctx, websocket.MessageBinary, pcm)
+}
+
+func (s *voskStreamSession) CloseSend() error {
+	s.closing.Store(true)
+	return s.conn.Write(s.ctx, websocket.MessageText, []byte(voskEOF))
+}
+
+func (s *voskStreamSession) read() ([]byte, error) {
+	if data := s.ahead; data != nil {
+		s.ahead = nil
+		return data, nil
+	}
+	_, data, err := s.conn.Read(s.ctx)
+	return data, err
+}
+
+func (s *voskStreamSession) Recv() (streamUpdate, error) {
+	if s.flushed {
+		// vosk-server hangs up right after the eof result; that is the normal
+		// end, not a failure, so wait for our own Close instead of reading it.
+		<-s.ctx.Done()
+		return streamUpdate{}, s.ctx.Err()
+	}
+	data, err := s.read()
+	if err != nil {
+		return streamUpdate{}, err
+	}
+
+	var msg voskStreamMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return streamUpdate{}, err
+	}
+
+	if msg.Text != nil {
+		fromFinalize := false
+		if s.closing.Load() {
+			// A result still in flight for earlier audio is followed by more;
+			// the eof result by the end of the connection.
+			_, next, err := s.conn.Read(s.ctx)
+			if err != nil {
+				fromFinalize, s.flushed = true, true
+			} else {
+				s.ahead = next
+			}
+		}
+		return streamUpdate{
+			Transcript:   strings.TrimSpace(*msg.Text),
+			IsFinal:      true,
+			FromFinalize: fromFinalize,
+		}, nil
+	}
+	var partial string
+	if msg.Partial != nil {
+		partial = strings.TrimSpace(*msg.Partial)
+	}
+	return streamUpdate{Transcript: partial}, nil
+}
+
+func (s *voskStreamSession) Close() error {
+	s.cancel()
+	return s.conn.Close(websocket.StatusNormalClosure, "")
+}
@@ -0,0 +1,124 @@
+package transcriber
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nhooyr.io/websocket"
+
+	"zee/audio"
+)
+
+// fakeVoskServer mimics vosk-server: a config message first, then partials
+// and finals that need not line up one-to-one with the PCM frames, and a
+// final flush on {"eof" : 1} before it hangs up.
+func fakeVoskServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.CloseNow()
+		ctx := r.Context()
+		write := func(v string) { c.Write(ctx, websocket.MessageText, []byte(v)) }
+
+		if _, data, err := c.Read(ctx); err != nil || !strings.Contains(string(data), `"sample_rate":16000`) {
+			t.Errorf("first message = %q, want the config", data)
+			return
+		}
+		frames := 0
+		for {
+			typ, data, err := c.Read(ctx)
+			if err != nil {
+				return
+			}
+			if typ == websocket.MessageText {
+				if string(data) != `{"eof" : 1}` {
+					t.Errorf("text message = %q, want the exact eof string", data)
+				}
+				write(`{"partial":"wor"}`)
+				write(`{"text":"world"}`)
+				return
+			}
+			frames++
+			switch {
+			case frames == 1:
+				write(`{"partial":"hel"}`)
+			case frames == 2:
+				write(`{"partial":"hello"}`)
+				write(`{"result":[],"text":"hello"}`)
+			case frames%2 == 0:
+				write(`{"partial":""}`)
+			}
+		}
+	}))
+}
+
+func TestVoskStreamSession(t *testing.T) {
+	srv := fakeVoskServer(t)
+	defer srv.Close()
+
+	v := NewVosk("ws" + strings.TrimPrefix(srv.URL, "http"))
+	sess, err := v.NewSession(context.Background(), SessionConfig{Stream: true})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	go func() {
+		for range sess.Updates() {
+		}
+	}()
+	sess.Feed(testPCM())
+	res, err := sess.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if res.Text != "hello world" {
+		t.Errorf("Text = %q, want %q", res.Text, "hello world")
+	}
+	if res.Stream == nil || res.Stream.CommitEvents != 2 {
+		t.Errorf("Stream stats = %+v, want 2 commits", res.Stream)
+	}
+	if res.AudioFormat != "wav" || len(res.AudioData) == 0 {
+		t.Error("session audio not retained")
+	}
+}
+
+// TestVoskTranscribeFile covers the -transcribe/fallback path: a WAV is
+// streamed through a session; compressed input is refused up front.
+func TestVoskTranscribeFile(t *testing.T) {
+	srv := fakeVoskServer(t)
+	defer srv.Close()
+
+	v := NewVosk("ws" + strings.TrimPrefix(srv.URL, "http"))
+	res, err := v.Transcribe(audio.PCMToWAV(testPCM()), "wav", "", "")
+	if err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+	if res.Text != "hello world" {
+		t.Errorf("Text = %q, want %q", res.Text, "hello world")
+	}
+	if _, err := v.Transcribe([]byte("ID3"), "mp3", "", ""); err == nil {
+		t.Error("mp3 input should be refused")
+	}
+}
+
+func TestVoskGatedOnServer(t *testing.T) {
+	t.Cleanup(func() { SetServerSource(func(string) Server { return Server{} }) })
+
+	SetServerSource(func(string) Server { return Server{} })
+	if providerNamed(t, "vosk").Available() {
+		t.Error("vosk should be unavailable with no server configured")
+	}
+	SetServerSource(func(p string) Server {
+		if p == "vosk" {
+			return Server{Addr: "ws://localhost:2700"}
+		}
+		return Server{}
+	})
+	if p := providerNamed(t, "vosk"); !p.Available() || !p.Keyless {
+		t.Error("vosk should be available (and keyless) once its server is configured")
+	}
+}
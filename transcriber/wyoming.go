@@ -0,0 +1,324 @@
+package transcriber
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"zee/audio"
+	"zee/encoder"
+)
+
+// Wyoming speaks Home Assistant's Wyoming protocol over TCP, which fronts a
+// whole ecosystem of self-hosted engines (wyoming-faster-whisper, -vosk, …).
+// Each event is one JSON header line, optionally followed by a JSON data block
+// and a binary payload whose lengths the header gives. A transcription is
+// transcribe → audio-start → audio-chunk… → audio-stop, answered by transcript.
+
+const wyomingModelID = "wyoming"
+
+var WyomingModels = []ModelInfo{
+	{ID: wyomingModelID, Label: "Wyoming server", Stream: false, Languages: whisperLangs},
+}
+
+// wyomingTimeout bounds the whole exchange after audio-stop: the server is on
+// the LAN, so a stall means it is wedged, not slow.
+const wyomingTimeout = 60 * time.Second
+
+type Wyoming struct {
+	baseTranscriber
+	addr string
+}
+
+func NewWyoming(addr string) *Wyoming {
+	return &Wyoming{
+		baseTranscriber: baseTranscriber{model: wyomingModelID},
+		addr:            strings.TrimSpace(addr),
+	}
+}
+
+func (w *Wyoming) SupportedLanguages() []Language { return whisperLangs }
+func (w *Wyoming) Name() string                   { return "wyoming" }
+func (w *Wyoming) Models() []ModelInfo            { return WyomingModels }
+
+// wyomingProvider is gated on config.json's "wyoming" host, like vosk.
+func wyomingProvider() ProviderInfo {
+	const name = "wyoming"
+	configured := func() bool { return serverSource(name).Addr != "" }
+	return ProviderInfo{
+		Name:      name,
+		Label:     "Wyoming server",
+		Models:    WyomingModels,
+		Keyless:   true,
+		Available: configured,
+		New:       func() Transcriber { return NewWyoming(serverSource(name).Addr) },
+		Status:    func(string) ModelStatus { return ModelStatus{Ready: configured()} },
+	}
+}
+
+// NewSession opens the connection up front so the audio streams to the server
+// while the user speaks; Close then only waits for the transcript. Wyoming
+// ASR servers answer once, at audio-stop, so the session is batch-shaped.
+func (w *Wyoming) NewSession(ctx context.Context, cfg SessionConfig) (Session, error) {
+	if w.addr == "" {
+		return nil, fmt.Errorf("wyoming: no server configured")
+	}
+	s := &wyomingSession{
+		updates:   make(chan string),
+		audioCh:   make(chan []byte, 128),
+		connected: make(chan struct{}),
+		sendDone:  make(chan struct{}),
+	}
+	go s.run(ctx, w.addr, cfg.Language)
+	return s, nil
+}
+
+// Transcribe decodes an audio file and streams it through a session, for
+// -transcribe and the fallback chain.
+func (w *Wyoming) Transcribe(audioData []byte, format, lang, _ string) (*Result, error) {
+	pcm, err := audio.DecodeToPCM(audioData, format)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := w.NewSession(context.Background(), SessionConfig{Language: lang})
+	if err != nil {
+		return nil, err
+	}
+	for len(pcm) > 0 {
+		n := min(len(pcm), streamChunkBytes)
+		sess.Feed(pcm[:n])
+		pcm = pcm[n:]
+	}
+	sr, err := sess.Close()
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Text: sr.Text}
+	if sr.Batch != nil {
+		res.InferenceMs = sr.Batch.InferenceMs
+	}
+	return res, nil
+}
+
+type wyomingSession struct {
+	updates   chan string
+	audioCh   chan []byte
+	connected chan struct{} // closed once the dial finished (either way)
+	sendDone  chan struct{} // closed when the sender has drained audioCh
+
+	conn net.Conn
+	rd   *bufio.Reader
+
+	mu      sync.Mutex
+	err     error
+	pcm     []byte // full session PCM, returned as WAV in AudioData
+	sent    int
+	connDur time.Duration
+}
+
+func (s *wyomingSession) setErr(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+}
+
+func (s *wyomingSession) run(ctx context.Context, addr, lang string) {
+	defer close(s.sendDone)
+
+	start := time.Now()
+	conn, err := (&net.Dialer{Timeout: 10 * time.Second}).DialContext(ctx, "tcp", addr)
+	s.mu.Lock()
+	s.connDur = time.Since(start)
+	s.mu.Unlock()
+	if err == nil {
+		s.conn, s.rd = conn, bufio.NewReader(conn)
+		fmtData := wyomingAudioFormat()
+		transcribe := map[string]any{}
+		if lang != "" {
+			transcribe["language"] = lang
+		}
+		if err = writeWyomingEvent(conn, "transcribe", transcribe, nil); err == nil {
+			err = writeWyomingEvent(conn, "audio-start", fmtData, nil)
+		}
+	}
+	if err != nil {
+		s.setErr(fmt.Errorf("wyoming: %w", err))
+	}
+	close(s.connected)
+
+	for chunk := range s.audioCh {
+		s.mu.Lock()
+		failed := s.err != nil
+		s.mu.Unlock()
+		if failed {
+			continue // keep draining so Feed never blocks
+		}
+		if err := writeWyomingEvent(s.conn, "audio-chunk", wyomingAudioFormat(), chunk); err != nil {
+			s.setErr(fmt.Errorf("wyoming: %w", err))
+			continue
+		}
+		s.mu.Lock()
+		s.sent += len(chunk)
+		s.mu.Unlock()
+	}
+}
+
+func wyomingAudioFormat() map[string]any {
+	return map[string]any{
+		"rate":     encoder.SampleRate,
+		"width":    encoder.BitsPerSample / 8,
+		"channels": encoder.Channels,
+	}
+}
+
+func (s *wyomingSession) Feed(pcm []byte) {
+	chunk := make([]byte, len(pcm))
+	copy(chunk, pcm)
+	s.mu.Lock()
+	s.pcm = append(s.pcm, chunk...)
+	s.mu.Unlock()
+	s.audioCh <- chunk
+}
+
+func (s *wyomingSession) Updates() <-chan string { return s.updates }
+
+func (s *wyomingSession) Close() (SessionResult, error) {
+	close(s.updates)
+	close(s.audioCh)
+	<-s.sendDone
+
+	s.mu.Lock()
+	pcm, err, sent, connDur := s.pcm, s.err, s.sent, s.connDur
+	s.mu.Unlock()
+	if s.conn != nil {
+		defer s.conn.Close()
+	}
+
+	var sr SessionResult
+	if len(pcm) > 0 {
+		sr.AudioData = audio.PCMToWAV(pcm)
+		sr.AudioFormat = "wav"
+	}
+	if err != nil {
+		return sr, err
+	}
+
+	start := time.Now()
+	s.conn.SetDeadline(start.Add(wyomingTimeout))
+	if err := writeWyomingEvent(s.conn, "audio-stop", nil, nil); err != nil {
+		return sr, fmt.Errorf("wyoming: %w", err)
+	}
+	var text string
+	for {
+		ev, err := readWyomingEvent(s.rd)
+		if err != nil {
+			return sr, fmt.Errorf("wyoming: waiting for transcript: %w", err)
+		}
+		if ev.Type == "error" {
+			return sr, fmt.Errorf("wyoming server error: %s", ev.Data["text"])
+		}
+		if ev.Type == "transcript" {
+			text, _ = ev.Data["text"].(string)
+			break
+		}
+	}
+	waitMs := float64(time.Since(start).Microseconds()) / 1000
+
+	text = strings.TrimSpace(text)
+	audioSec := float64(len(pcm)) / float64(encoder.SampleRate*encoder.Channels*encoder.BitsPerSample/8)
+	sr.Text = text
+	sr.HasText = text != ""
+	sr.NoSpeech = text == ""
+	sr.Batch = &BatchStats{
+		AudioLengthS: audioSec,
+		RawSizeKB:    float64(len(pcm)) / 1024,
+		InferenceMs:  waitMs,
+		TotalTimeMs:  waitMs,
+	}
+	sr.Metrics = []string{
+		fmt.Sprintf("audio:      %.1fs | %.1f KB PCM streamed during recording", audioSec, float64(sent)/1024),
+		fmt.Sprintf("connect:    %dms", connDur.Milliseconds()),
+		fmt.Sprintf("transcript: %.0fms after audio-stop", waitMs),
+	}
+	sr.captureRSS()
+	return sr, nil
+}
+
+// wyomingEvent is one decoded event. Data merges the header's inline "data"
+// with the separate data block, as the protocol allows either.
+type wyomingEvent struct {
+	Type    string
+	Data    map[string]any
+	Payload []byte
+}
+
+func writeWyomingEvent(w io.Writer, typ string, data map[string]any, payload []byte) error {
+	header := map[string]any{"type": typ}
+	var dataBytes []byte
+	if len(data) > 0 {
+		var err error
+		if dataBytes, err = json.Marshal(data); err != nil {
+			return err
+		}
+		header["data_length"] = len(dataBytes)
+	}
+	if len(payload) > 0 {
+		header["payload_length"] = len(payload)
+	}
+	line, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 0, len(line)+1+len(dataBytes)+len(payload))
+	buf = append(append(append(append(buf, line...), '\n'), dataBytes...), payload...)
+	_, err = w.Write(buf)
+	return err
+}
+
+func readWyomingEvent(r *bufio.Reader) (wyomingEvent, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return wyomingEvent{}, err
+	}
+	var header struct {
+		Type          string         `json:"type"`
+		Data          map[string]any `json:"data"`
+		DataLength    int            `json:"data_length"`
+		PayloadLength int            `json:"payload_length"`
+	}
+	if err := json.Unmarshal(line, &header); err != nil {
+		return wyomingEvent{}, fmt.Errorf("bad event header: %w", err)
+	}
+	ev := wyomingEvent{Type: header.Type, Data: header.Data}
+	if ev.Data == nil {
+		ev.Data = map[string]any{}
+	}
+	if header.DataLength > 0 {
+		block := make([]byte, header.DataLength)
+		if _, err := io.ReadFull(r, block); err != nil {
+			return wyomingEvent{}, err
+		}
+		var extra map[string]any
+		if err := json.Unmarshal(block, &extra); err != nil {
+			return wyomingEvent{}, fmt.Errorf("bad event data: %w", err)
+		}
+		for k, v := range extra {
+			ev.Data[k] = v
+		}
+	}
+	if header.PayloadLength > 0 {
+		ev.Payload = make([]byte, header.PayloadLength)
+		if _, err := io.ReadFull(r, ev.Payload); err != nil {
+			return wyomingEvent{}, err
+		}
+	}
+	return ev, nil
+}
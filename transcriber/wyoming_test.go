@@ -0,0 +1,133 @@
+package transcriber
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"zee/audio"
+)
+
+// fakeWyomingServer accepts one connection and plays an ASR service: it
+// checks the event sequence, counts the PCM it receives, and answers
+// audio-stop with a transcript naming what it heard.
+func fakeWyomingServer(t *testing.T, gotPCM *bytes.Buffer, gotLang *string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rd := bufio.NewReader(conn)
+		want := "transcribe"
+		for {
+			ev, err := readWyomingEvent(rd)
+			if err != nil {
+				return
+			}
+			switch ev.Type {
+			case "transcribe":
+				*gotLang, _ = ev.Data["language"].(string)
+				want = "audio-start"
+			case "audio-start":
+				if want != "audio-start" || ev.Data["rate"] != float64(16000) {
+					writeWyomingEvent(conn, "error", map[string]any{"text": "bad audio-start"}, nil)
+					return
+				}
+			case "audio-chunk":
+				gotPCM.Write(ev.Payload)
+			case "audio-stop":
+				writeWyomingEvent(conn, "transcript", map[string]any{"text": " hello wyoming "}, nil)
+				return
+			}
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestWyomingSession(t *testing.T) {
+	var gotPCM bytes.Buffer
+	var gotLang string
+	w := NewWyoming(fakeWyomingServer(t, &gotPCM, &gotLang))
+
+	sess, err := w.NewSession(context.Background(), SessionConfig{Language: "de"})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	pcm := testPCM()
+	sess.Feed(pcm[:1000])
+	sess.Feed(pcm[1000:])
+	res, err := sess.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if res.Text != "hello wyoming" || !res.HasText {
+		t.Errorf("Text = %q, want %q", res.Text, "hello wyoming")
+	}
+	if !bytes.Equal(gotPCM.Bytes(), pcm) {
+		t.Errorf("server got %d PCM bytes, want %d identical", gotPCM.Len(), len(pcm))
+	}
+	if gotLang != "de" {
+		t.Errorf("language = %q, want de", gotLang)
+	}
+	if res.AudioFormat != "wav" || res.Batch == nil {
+		t.Error("session should keep its audio and batch stats")
+	}
+}
+
+func TestWyomingTranscribeFile(t *testing.T) {
+	var gotPCM bytes.Buffer
+	var gotLang string
+	w := NewWyoming(fakeWyomingServer(t, &gotPCM, &gotLang))
+	res, err := w.Transcribe(audio.PCMToWAV(testPCM()), "wav", "", "")
+	if err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+	if res.Text != "hello wyoming" {
+		t.Errorf("Text = %q", res.Text)
+	}
+}
+
+func TestWyomingUnreachable(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close() // nothing listens here now
+
+	sess, err := NewWyoming(addr).NewSession(context.Background(), SessionConfig{})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	sess.Feed(testPCM())
+	res, err := sess.Close()
+	if err == nil {
+		t.Fatal("Close should fail when the server is unreachable")
+	}
+	if len(res.AudioData) == 0 {
+		t.Error("a failed session must still hand back its audio")
+	}
+}
+
+// TestWyomingEventRoundTrip: data may arrive inline in the header or as a
+// separate block; both must decode, and payloads must survive intact.
+func TestWyomingEventRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	writeWyomingEvent(&buf, "audio-chunk", map[string]any{"rate": 16000}, []byte{1, 2, 3})
+	buf.WriteString(`{"type":"transcript","data":{"text":"inline"}}` + "\n")
+
+	rd := bufio.NewReader(&buf)
+	ev, err := readWyomingEvent(rd)
+	if err != nil || ev.Type != "audio-chunk" || ev.Data["rate"] != float64(16000) || !bytes.Equal(ev.Payload, []byte{1, 2, 3}) {
+		t.Errorf("audio-chunk = %+v, %v", ev, err)
+	}
+	ev, err = readWyomingEvent(rd)
+	if err != nil || ev.Type != "transcript" || ev.Data["text"] != "inline" {
+		t.Errorf("transcript = %+v, %v", ev, err)
+	}
+}
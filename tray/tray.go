@@ -75,6 +75,9 @@ var (
 	editCredsCb    func()
 	reloadCfgCb    func()
 	hotkeyLabel    string // display-only current push-to-talk combo (e.g. "⌥Space")
+
+	recentTitles []string // the Recent submenu, newest first
+	recentCb     func(i int)
 )
 
 var languages []transcriber.Language // set via SetLanguages
@@ -213,8 +216,31 @@ func modelTitle(m Model) string {
 	}
 }
 
-func SetLastRecording(dur time.Duration, totalMs float64) {
-	updateCopyLastTitle(fmt.Sprintf("Copy Last Recorded Text (%.1fs | %dms)", dur.Seconds(), int(totalMs)))
+// SetLastRecording retitles "Copy Last Recorded Text" with the recording's
+// length and latency. via names the fallback provider when the active one
+// failed and another produced the text; "" for a normal transcription.
+func SetLastRecording(dur time.Duration, totalMs float64, via string) {
+	title := fmt.Sprintf("Copy Last Recorded Text (%.1fs | %dms)", dur.Seconds(), int(totalMs))
+	if via != "" {
+		title = fmt.Sprintf("Copy Last Recorded Text (%.1fs | %dms via %s)", dur.Seconds(), int(totalMs), via)
+	}
+	updateCopyLastTitle(title)
+}
+
+// MaxRecent is how many dictations the Recent submenu holds.
+const MaxRecent = 10
+
+// SetRecent fills the Recent submenu, newest first, with up to MaxRecent
+// titles; picking the i-th calls onPick(i) (nil keeps the previous handler).
+// Safe to call before Init and from any goroutine.
+func SetRecent(titles []string, onPick func(i int)) {
+	trayMu.Lock()
+	recentTitles = titles[:min(len(titles), MaxRecent)]
+	if onPick != nil {
+		recentCb = onPick
+	}
+	trayMu.Unlock()
+	updateRecentItems()
 }
 
 // hintsEnabled gates the "Edit Hints…" item: local providers ignore hints
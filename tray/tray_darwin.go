@@ -13,6 +13,8 @@ var (
 	mStatus        *systray.MenuItem
 	mRecord        *systray.MenuItem
 	mCopy          *systray.MenuItem
+	mRecent        *systray.MenuItem
+	recentItems    []*systray.MenuItem
 	mDevices       *systray.MenuItem
 	mDefaultDevice *systray.MenuItem
 	deviceItems    []*systray.MenuItem
@@ -228,6 +230,23 @@ func onReady() {
 		}
 	})
 
+	// Recent: MaxRecent items made up front and retitled or hidden as the
+	// history moves — systray can't remove menu items.
+	mRecent = systray.AddMenuItem("Recent", "Paste a recent dictation again")
+	for i := range MaxRecent {
+		item := mRecent.AddSubMenuItem("", "")
+		item.Click(func() {
+			trayMu.Lock()
+			cb := recentCb
+			trayMu.Unlock()
+			if cb != nil {
+				go cb(i)
+			}
+		})
+		recentItems = append(recentItems, item)
+	}
+	updateRecentItems()
+
 	mSave := systray.AddMenuItem("Save Last Recording", "Save last audio + metadata to disk")
 	mSave.Click(func() {
 		if saveAudioCb != nil {
@@ -436,6 +455,28 @@ func onReady() {
 	close(deviceReady)
 }
 
+func updateRecentItems() {
+	if mRecent == nil {
+		return
+	}
+	trayMu.Lock()
+	titles := recentTitles
+	trayMu.Unlock()
+	for i, item := range recentItems {
+		if i < len(titles) {
+			item.SetTitle(titles[i])
+			item.Show()
+		} else {
+			item.Hide()
+		}
+	}
+	if len(titles) == 0 {
+		mRecent.Disable()
+	} else {
+		mRecent.Enable()
+	}
+}
+
 func updateCopyLastTitle(title string) {
 	if mCopy != nil {
 		mCopy.SetTitle(title)
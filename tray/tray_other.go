@@ -19,3 +19,4 @@ func setHintsEnabled(bool)                           {}
 func updateAutoPasteItem(bool)                       {}
 func updateLoginItem(bool)                           {}
 func updateHotkeyDisplay()                           {}
+func updateRecentItems()                             {}
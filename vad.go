@@ -73,6 +73,17 @@ func (p *vadProcessor) Process(data []byte) {
 	}
 }
 
+// IsSpeech classifies one vadFrameBytes frame without touching the running
+// state — for the chunker, which needs to know where the pauses are rather than
+// whether the user is speaking now. A frame the VAD rejects counts as speech, so
+// it is never mistaken for a pause to cut at.
+func (p *vadProcessor) IsSpeech(frame []byte) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	active, err := p.vad.Process(encoder.SampleRate, frame)
+	return err != nil || active
+}
+
 // SpeakingNow reports whether the VAD called speech within the last window.
 // It deliberately skips the debounce VoiceDetected applies: this drives the
 // level meter, where reacting to the first frame of a word matters more than
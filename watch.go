@@ -0,0 +1,243 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// `zee watch <dir>` turns the transcribeFile path into a hands-off pipeline for
+// voice recorders and phones that sync into a folder: every audio file that
+// lands in dir is transcribed once it stops changing, and its sidecar written
+// beside it (or into -outdir). Finished files go into the same JSONL manifest
+// `zee transcribe` keeps, so a restart neither redoes them nor misses what
+// arrived while zee was off.
+//
+// Change notification is inotify on Linux (watch_linux.go) and a directory
+// poll elsewhere; either way it only nominates candidates, and the settle
+// check decides when one is complete — a sync client may create a file long
+// before it finishes writing it.
+
+const (
+	watchPollInterval = 2 * time.Second
+	// watchSettle is how long a file's size and mtime must hold still before
+	// it is taken to be complete.
+	watchSettle = 3 * time.Second
+)
+
+type watchOptions struct {
+	format   string // an outputFormats key
+	outDir   string
+	manifest string        // "" = batchManifestName in outDir or dir
+	settle   time.Duration // 0 = watchSettle
+}
+
+// fileStamp is a candidate's size and mtime, and when they were first seen
+// at those values.
+type fileStamp struct {
+	size  int64
+	mod   time.Time
+	since time.Time
+}
+
+// runWatch watches dir until ctx is done and returns the exit code. Progress
+// goes to out, one line per file; setup errors, the manifest's and a switch
+// to polling go to errOut.
+func runWatch(ctx context.Context, dir string, opt watchOptions, out, errOut io.Writer) int {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fmt.Fprintf(errOut, "zee watch: %s is not a directory\n", dir)
+		return 1
+	}
+	settle := opt.settle
+	if settle == 0 {
+		settle = watchSettle
+	}
+	manifestPath := opt.manifest
+	if manifestPath == "" {
+		manifestPath = filepath.Join(cmp.Or(opt.outDir, dir), batchManifestName)
+	}
+	done, err := loadManifest(manifestPath, opt.format, opt.outDir)
+	if err != nil {
+		fmt.Fprintf(errOut, "zee watch: manifest: %v\n", err)
+		return 1
+	}
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		fmt.Fprintf(errOut, "zee watch: manifest: %v\n", err)
+		return 1
+	}
+	mf, err := os.OpenFile(manifestPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(errOut, "zee watch: manifest: %v\n", err)
+		return 1
+	}
+	defer mf.Close()
+
+	events, how, err := watchEvents(ctx, dir)
+	if err != nil {
+		fmt.Fprintf(errOut, "zee watch: %v; polling instead\n", err)
+		events, how = pollEvents(ctx, dir), "polling"
+	}
+	fmt.Fprintf(out, "watching %s (%s); manifest %s\n", dir, how, manifestPath)
+
+	pending := map[string]fileStamp{}
+	failed := map[string]time.Time{} // mtime at the failed attempt
+	consider := func(path string) {
+		if done[path] || !slices.Contains(batchAudioExts, strings.ToLower(filepath.Ext(path))) {
+			return
+		}
+		if _, ok := pending[path]; !ok {
+			pending[path] = fileStamp{}
+		}
+	}
+	// Whatever arrived while zee wasn't running.
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() {
+				consider(filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+
+	// Files are transcribed one at a time off the event loop, so it keeps
+	// draining inotify while a long file is in flight; results is nil when
+	// no work is.
+	var results chan manifestEntry
+	batch := batchOptions{format: opt.format, outDir: opt.outDir}
+	transcribeAll := func(paths []string) chan manifestEntry {
+		ch := make(chan manifestEntry)
+		go func() {
+			defer close(ch)
+			for _, path := range paths {
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case ch <- transcribeOne(path, batch):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch
+	}
+
+	tick := time.NewTicker(max(settle/4, 10*time.Millisecond))
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case path, ok := <-events:
+			if !ok {
+				// The notifier gave up (its descriptor failed, or dir's watch
+				// was removed); polling keeps the folder covered.
+				if ctx.Err() == nil {
+					fmt.Fprintf(errOut, "zee watch: %s stopped; polling instead\n", how)
+					events, how = pollEvents(ctx, dir), "polling"
+				}
+				continue
+			}
+			consider(path)
+		case e, ok := <-results:
+			if !ok {
+				results = nil
+				continue
+			}
+			line, _ := json.Marshal(e)
+			if _, err := mf.Write(append(line, '\n')); err != nil {
+				fmt.Fprintf(errOut, "zee watch: manifest: %v\n", err)
+			}
+			if e.Status == "done" {
+				done[e.File] = true
+				delete(failed, e.File)
+				fmt.Fprintf(out, "done  %s -> %s\n", e.File, e.Output)
+				continue
+			}
+			if info, err := os.Stat(e.File); err == nil {
+				failed[e.File] = info.ModTime()
+			}
+			fmt.Fprintf(out, "FAIL  %s: %s\n", e.File, e.Error)
+		case now := <-tick.C:
+			var ready []string
+			for path, prev := range pending {
+				info, err := os.Stat(path)
+				if err != nil {
+					delete(pending, path) // moved away or deleted
+					continue
+				}
+				if mod, ok := failed[path]; ok && mod.Equal(info.ModTime()) {
+					delete(pending, path) // unchanged since it failed
+					continue
+				}
+				cur := fileStamp{size: info.Size(), mod: info.ModTime(), since: now}
+				if cur.size != prev.size || !cur.mod.Equal(prev.mod) {
+					pending[path] = cur
+					continue
+				}
+				if cur.size > 0 && now.Sub(prev.since) >= settle {
+					ready = append(ready, path)
+				}
+			}
+			if results != nil || len(ready) == 0 {
+				continue // still busy: ready files stay pending for the next tick
+			}
+			slices.Sort(ready)
+			for _, path := range ready {
+				delete(pending, path)
+			}
+			results = transcribeAll(ready)
+		}
+	}
+}
+
+// pollEvents nominates every file in dir each watchPollInterval; the settle
+// check in runWatch does the rest.
+func pollEvents(ctx context.Context, dir string) <-chan string {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		t := time.NewTicker(watchPollInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			if !nominateAll(ctx, dir, ch) {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// nominateAll sends every file in dir on ch, for a poll or after inotify lost
+// events; it reports false once ctx is done.
+func nominateAll(ctx context.Context, dir string, ch chan<- string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return true
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		select {
+		case ch <- filepath.Join(dir, e.Name()):
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
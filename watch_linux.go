@@ -0,0 +1,72 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchEvents nominates files in dir as inotify reports them created, written
+// or moved in. The descriptor is non-blocking so the read goes through Go's
+// poller and closing it on ctx.Done unblocks the reader. When the kernel's
+// queue overflows the dropped events are unknown, so every file in dir is
+// nominated again; the channel closes if the watch itself goes away.
+func watchEvents(ctx context.Context, dir string) (<-chan string, string, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, "", fmt.Errorf("inotify: %w", err)
+	}
+	mask := uint32(unix.IN_CREATE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO)
+	if _, err := unix.InotifyAddWatch(fd, dir, mask); err != nil {
+		unix.Close(fd)
+		return nil, "", fmt.Errorf("inotify: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				return
+			}
+			for off := 0; off+unix.SizeofInotifyEvent <= n; {
+				ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+				name := buf[off+unix.SizeofInotifyEvent : off+unix.SizeofInotifyEvent+int(ev.Len)]
+				off += unix.SizeofInotifyEvent + int(ev.Len)
+				name = bytes.TrimRight(name, "\x00")
+				switch {
+				case ev.Mask&unix.IN_Q_OVERFLOW != 0:
+					if !nominateAll(ctx, dir, ch) {
+						return
+					}
+					continue
+				case ev.Mask&unix.IN_IGNORED != 0:
+					return // dir was deleted or unmounted
+				}
+				if ev.Mask&unix.IN_ISDIR != 0 || len(name) == 0 {
+					continue
+				}
+				select {
+				case ch <- filepath.Join(dir, string(name)):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, "inotify", nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import "context"
+
+// watchEvents polls on macOS and Windows: native notification needs cgo (and
+// a run loop on macOS), and files that take seconds to settle gain nothing
+// from hearing about them sooner than watchPollInterval.
+func watchEvents(ctx context.Context, dir string) (<-chan string, string, error) {
+	return pollEvents(ctx, dir), "polling", nil
+}
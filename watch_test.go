@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"zee/transcriber"
+)
+
+// syncBuffer is a bytes.Buffer safe to read while runWatch writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// startWatch runs runWatch on dir in the background; the returned func stops
+// it and returns its output.
+func startWatch(t *testing.T, dir string) (stop func() string) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	var out, errOut syncBuffer
+	exited := make(chan int)
+	go func() {
+		exited <- runWatch(ctx, dir, watchOptions{format: "json", settle: 100 * time.Millisecond}, &out, &errOut)
+	}()
+	return func() string {
+		cancel()
+		if code := <-exited; code != 0 {
+			t.Errorf("runWatch exited %d", code)
+		}
+		if s := errOut.String(); s != "" {
+			t.Errorf("stderr: %s", s)
+		}
+		return out.String()
+	}
+}
+
+func TestWatchErrorsGoToStderr(t *testing.T) {
+	var out, errOut bytes.Buffer
+	missing := filepath.Join(t.TempDir(), "missing")
+	if code := runWatch(context.Background(), missing, watchOptions{format: "text"}, &out, &errOut); code != 1 {
+		t.Errorf("exit %d, want 1", code)
+	}
+	if out.Len() != 0 || !strings.Contains(errOut.String(), "is not a directory") {
+		t.Errorf("stdout %q, stderr %q", out.String(), errOut.String())
+	}
+}
+
+func waitForFile(t *testing.T, path string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+	}
+	t.Fatalf("%s never appeared", path)
+}
+
+func TestWatchTranscribesNewFilesOnce(t *testing.T) {
+	orig := activeTranscriber
+	t.Cleanup(func() { activeTranscriber = orig })
+	activeTranscriber = transcriber.NewFake("hello", nil)
+	dir := t.TempDir()
+	before := writeClips(t, dir, "before.wav")[0] // there before the watch started
+
+	stop := startWatch(t, dir)
+	waitForFile(t, sidecarPath(before, "json", ""))
+	after := writeClips(t, dir, "after.wav")[0]
+	waitForFile(t, sidecarPath(after, "json", ""))
+	first := stop()
+
+	data, _ := os.ReadFile(sidecarPath(after, "json", ""))
+	if !strings.Contains(string(data), `"text": "hello"`) {
+		t.Errorf("sidecar = %s", data)
+	}
+
+	// A restart finds both in the manifest and leaves them alone.
+	stop = startWatch(t, dir)
+	time.Sleep(400 * time.Millisecond)
+	second := stop()
+	if strings.Count(first, "done  ") != 2 || strings.Contains(second, "done  ") {
+		t.Errorf("first run:\n%s\nsecond run:\n%s", first, second)
+	}
+	manifest, _ := os.ReadFile(filepath.Join(dir, batchManifestName))
+	if n := strings.Count(string(manifest), "\n"); n != 2 {
+		t.Errorf("manifest has %d entries, want 2:\n%s", n, manifest)
+	}
+}